@@ -34,9 +34,41 @@ var runCmd = &cobra.Command{
 	Long: `Run test scenarios from YAML files. You can specify:
   - A single YAML file: ezpw run test.yml
   - A directory: ezpw run ./tests/
-  - Multiple files: ezpw run test1.yml test2.yml`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: cli.RunCommand,
+  - Multiple files: ezpw run test1.yml test2.yml
+
+Every file runs even when others fail, unless --fail-fast is given. A summary
+of all scenarios is printed at the end, and the command exits with a non-zero
+status when any scenario failed.`,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          cli.RunCommand,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [scenario-file-or-directory]",
+	Short: "Validate test scenarios without running them",
+	Long: `Parse and check scenario files without launching a browser.
+Every problem is reported with its file and line, and the command exits
+with a non-zero status when any file is invalid:
+  - A single YAML file: ezpw validate test.yml
+  - A directory: ezpw validate ./tests/`,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          cli.ValidateCommand,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of scenario files",
+	Long: `Print the JSON Schema of the scenario file format, for editor completion
+and validation. For the VS Code YAML extension, save it and reference it at
+the top of a scenario file:
+  ezpw schema -o ezpw.schema.json
+  # yaml-language-server: $schema=./ezpw.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: cli.SchemaCommand,
 }
 
 const defaultTimeout = 30000
@@ -44,18 +76,30 @@ const defaultTimeout = 30000
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(schemaCmd)
 
 	runCmd.Flags().StringP("browser", "b", "chromium", "Browser to use (chromium, firefox, webkit)")
 	runCmd.Flags().Bool("headless", true, "Run browser in headless mode")
 	runCmd.Flags().Bool("no-headless", false, "Run browser in non-headless mode")
 	runCmd.Flags().IntP("parallel", "p", 1, "Number of parallel executions")
 	runCmd.Flags().Int("timeout", defaultTimeout, "Global timeout in milliseconds")
+	runCmd.Flags().Int("scenario-timeout", 0, "Overall deadline for each scenario in milliseconds (0 means no deadline)")
+	runCmd.Flags().StringArray("var", nil, "Set a scenario variable as key=value (repeatable)")
 	runCmd.Flags().StringP("output", "o", "./reports", "Output directory for reports")
+	runCmd.Flags().String("trace", "off", "Record a Playwright trace of each scenario: on, off or retain-on-failure")
+	runCmd.Flags().String("video", "off", "Keep a video of each scenario: on, off or retain-on-failure")
+	runCmd.Flags().Bool("fail-fast", false, "Stop running scenarios after the first failure")
+	runCmd.Flags().Int("retries", 0, "Rerun a failed scenario up to this many times, reporting it flaky if it then passes")
 	runCmd.Flags().BoolP("verbose", "v", false, "Verbose output")
 	runCmd.Flags().Bool("debug", false, "Debug mode")
 	runCmd.Flags().Bool("auto-install", true,
 		"Automatically install browsers if missing (disable in CI with --no-auto-install)")
 	runCmd.Flags().Bool("no-auto-install", false, "Disable automatic browser installation")
+
+	validateCmd.Flags().BoolP("verbose", "v", false, "List valid files too")
+
+	schemaCmd.Flags().StringP("output", "o", "", "Write the schema to a file instead of stdout")
 }
 
 func main() {
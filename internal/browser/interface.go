@@ -1,13 +1,64 @@
 package browser
 
+import "github.com/haruotsu/ezpw/pkg/types"
+
+// Element states accepted by Page.WaitForSelector
+const (
+	StateAttached = "attached"
+	StateDetached = "detached"
+	StateVisible  = "visible"
+	StateHidden   = "hidden"
+)
+
+// Page load states accepted by Page.WaitForLoadState
+const (
+	LoadStateLoad             = "load"
+	LoadStateDOMContentLoaded = "domcontentloaded"
+	LoadStateNetworkIdle      = "networkidle"
+)
+
+// ClickOptions customizes a click made with Page.ClickWith
+type ClickOptions struct {
+	// Position relative to the top-left corner of the element, or of the page
+	// when no selector is given
+	Position *types.Point
+	// Button is left (default), right or middle
+	Button string
+	// Modifiers are the keys held during the click: Alt, Control, ControlOrMeta, Meta or Shift
+	Modifiers []string
+	// ClickCount is 2 for a double click (default 1)
+	ClickCount int
+}
+
+// SelectOptions identifies the options to select in a <select> element
+type SelectOptions struct {
+	Values  []string
+	Labels  []string
+	Indexes []int
+}
+
+// Option is an option of a <select> element
+type Option struct {
+	Value string
+	Label string
+}
+
 // Browser represents a browser instance interface
 type Browser interface {
-	// NewPage creates a new page/tab in the browser
+	// NewPage creates a new page/tab in its own isolated browser context
 	NewPage() (Page, error)
+	// NewPageWith creates a new page like NewPage with extra context options
+	NewPageWith(options PageOptions) (Page, error)
 	// Close closes the browser and cleans up resources
 	Close() error
 }
 
+// PageOptions configures the browser context of a new page
+type PageOptions struct {
+	// RecordVideo records a video of the page, kept with Page.SaveVideo
+	RecordVideo bool
+}
+
 // Page represents a browser page interface
 type Page interface {
 	// Navigation
@@ -20,16 +71,68 @@ type Page interface {
 	FillElement(selector, value string) error
 	Fill(selector, value string) error // Alias for backward compatibility
 
+	// Form controls
+	SelectOption(selector string, options SelectOptions) error // Replaces the current selection
+	Check(selector string) error
+	Uncheck(selector string) error
+	SetInputFiles(selector string, paths []string) error
+
+	// Mouse
+	Hover(selector string) error
+	ClickWith(selector string, options ClickOptions) error // An empty selector clicks options.Position on the page
+	DragAndDrop(source, target string) error
+
+	// Keyboard. An empty selector targets the focused element.
+	Press(selector, key string) error            // Key or chord such as "Enter" or "Control+K"
+	Type(selector, text string, delay int) error // Types text key by key, waiting delay ms between keys
+	KeyDown(key string) error
+	KeyUp(key string) error
+
 	// Getters
 	URL() string
+	Title() (string, error)
 	GetElementValue(selector string) (string, error)
 	InputValue(selector string) (string, error) // Alias for backward compatibility
+	GetElementAttribute(selector, name string) (string, error)
+	Evaluate(expression string) (interface{}, error)
 
 	// Content manipulation
 	SetContent(html string) error
+	Content() (string, error) // Full HTML of the page
+
+	// Screenshots, written as PNG files
+	Screenshot(path string, fullPage bool) error   // The viewport, or the whole scrollable page
+	ElementScreenshot(selector, path string) error // A single element
 
 	// Locator operations (for assertions)
 	GetElementCount(selector string) (int, error)
 	GetElementText(selector string) (string, error)
 	ElementExists(selector string) (bool, error)
+	IsChecked(selector string) (bool, error)
+	IsEnabled(selector string) (bool, error)
+	IsVisible(selector string) (bool, error)                  // False when no element matches
+	GetCSSProperty(selector, property string) (string, error) // Computed value of a CSS property
+	GetSelectedOptions(selector string) ([]Option, error)
+
+	// Waiting, up to the default timeout
+	WaitForSelector(selector, state string) error
+	WaitForURL(pattern string) error         // Exact URL or glob pattern such as "**/dashboard"
+	WaitForLoadState(state string) error     // load, domcontentloaded or networkidle
+	WaitForFunction(expression string) error // Until the JavaScript expression or function returns a truthy value
+
+	// Timeouts
+	SetTimeout(timeout int) // Default timeout in milliseconds for navigation, actions and waits
+
+	// SaveTrace stops the trace recorded since the page was created and writes
+	// it to path, or discards it when path is empty. It does nothing when
+	// tracing is off.
+	SaveTrace(path string) error
+
+	// SaveVideo sets where the video of a page created with RecordVideo is
+	// saved when the page closes; the video is discarded when path is empty,
+	// which is the default. It does nothing when the page records no video.
+	SaveVideo(path string)
+
+	// Close closes the page together with its browser context
+	Close() error
 }
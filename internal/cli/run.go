@@ -2,16 +2,13 @@ package cli
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
-	ezpwErrors "github.com/haruotsu/ezpw/internal/errors"
-	"github.com/haruotsu/ezpw/internal/executor"
-	"github.com/haruotsu/ezpw/internal/parser"
+	"github.com/haruotsu/ezpw/internal/vars"
 	"github.com/haruotsu/ezpw/pkg/types"
 	"github.com/spf13/cobra"
 )
@@ -22,9 +19,16 @@ func RunCommand(cmd *cobra.Command, args []string) error {
 	browser, _ := cmd.Flags().GetString("browser")
 	headless, _ := cmd.Flags().GetBool("headless")
 	noHeadless, _ := cmd.Flags().GetBool("no-headless")
+	parallel, _ := cmd.Flags().GetInt("parallel")
 	timeout, _ := cmd.Flags().GetInt("timeout")
+	scenarioTimeout, _ := cmd.Flags().GetInt("scenario-timeout")
+	retries, _ := cmd.Flags().GetInt("retries")
+	output, _ := cmd.Flags().GetString("output")
+	trace, _ := cmd.Flags().GetString("trace")
+	video, _ := cmd.Flags().GetString("video")
+	varAssignments, _ := cmd.Flags().GetStringArray("var")
 	verbose, _ := cmd.Flags().GetBool("verbose")
-	debug, _ := cmd.Flags().GetBool("debug")
+	failFast, _ := cmd.Flags().GetBool("fail-fast")
 	autoInstall, _ := cmd.Flags().GetBool("auto-install")
 	noAutoInstall, _ := cmd.Flags().GetBool("no-auto-install")
 
@@ -38,106 +42,108 @@ func RunCommand(cmd *cobra.Command, args []string) error {
 		autoInstall = false
 	}
 
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1, got %d", parallel)
+	}
+
+	if retries < 0 {
+		return fmt.Errorf("--retries must not be negative, got %d", retries)
+	}
+
+	if err := checkRecordMode("trace", trace); err != nil {
+		return err
+	}
+	if err := checkRecordMode("video", video); err != nil {
+		return err
+	}
+
+	cliVars := make(map[string]string, len(varAssignments))
+	for _, assignment := range varAssignments {
+		key, value, err := vars.ParseAssignment(assignment)
+		if err != nil {
+			return err
+		}
+		cliVars[key] = value
+	}
+
 	config := types.Config{
-		Browser:  browser,
-		Headless: headless,
-		Timeout:  timeout,
+		Browser:         browser,
+		Headless:        headless,
+		Timeout:         timeout,
+		ScenarioTimeout: scenarioTimeout,
+		OutputDir:       output,
+		Trace:           trace,
+		Video:           video,
+		Retries:         retries,
+		Vars:            cliVars,
 	}
 
 	if verbose {
-		fmt.Printf("Configuration: Browser=%s, Headless=%t, Timeout=%d\n", config.Browser, config.Headless, config.Timeout)
+		fmt.Printf("Configuration: Browser=%s, Headless=%t, Timeout=%d, Parallel=%d\n",
+			config.Browser, config.Headless, config.Timeout, parallel)
 	}
 
-	// Process each argument (file or directory)
+	// Collect scenario files from each argument (file or directory)
+	var files []string
 	for _, arg := range args {
-		err := processPath(arg, config, verbose, debug, autoInstall)
+		found, err := collectFiles(arg, verbose)
 		if err != nil {
 			return fmt.Errorf("failed to process %s: %w", arg, err)
 		}
+		files = append(files, found...)
 	}
 
-	return nil
+	r := newRunner(config, parallel, verbose, autoInstall)
+	r.failFast = failFast
+	return r.run(files)
+}
+
+// checkRecordMode validates the value of the --trace and --video flags
+func checkRecordMode(flag, mode string) error {
+	switch mode {
+	case types.RecordOff, types.RecordOn, types.RecordRetainOnFailure:
+		return nil
+	default:
+		return fmt.Errorf("--%s must be one of: on, off, retain-on-failure, got %q", flag, mode)
+	}
 }
 
-func processPath(path string, config types.Config, verbose, _, autoInstall bool) error {
+// collectFiles returns the scenario files for a file or directory path
+func collectFiles(path string, verbose bool) ([]string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("path does not exist: %s", path)
+		return nil, fmt.Errorf("path does not exist: %s", path)
 	}
 
 	if info.IsDir() {
-		return processDirectory(path, config, verbose, false, autoInstall)
+		return processDirectory(path, verbose)
 	}
-	return processFile(path, config, verbose, false, autoInstall)
+	return []string{path}, nil
 }
 
-func processDirectory(dirPath string, config types.Config, verbose, _, autoInstall bool) error {
+// processDirectory returns the YAML files found under dirPath in lexical order
+func processDirectory(dirPath string, verbose bool) ([]string, error) {
 	if verbose {
 		fmt.Printf("Processing directory: %s\n", dirPath)
 	}
 
-	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	var files []string
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if !info.IsDir() && (filepath.Ext(path) == ".yml" || filepath.Ext(path) == ".yaml") {
-			return processFile(path, config, verbose, false, autoInstall)
+			files = append(files, path)
 		}
 
 		return nil
 	})
-}
-
-func processFile(filePath string, config types.Config, verbose, _, autoInstall bool) error {
-	if verbose {
-		fmt.Printf("Processing file: %s\n", filePath)
-	}
-
-	// Parse YAML file
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	scenario, err := parser.ParseYAML(file)
-	if err != nil {
-		return fmt.Errorf("failed to parse YAML: %w", err)
-	}
-
-	if verbose {
-		fmt.Printf("Parsed scenario: %s with %d steps\n", scenario.Description, len(scenario.Steps))
-	}
-
-	// Create and run engine
-	engine, err := executor.NewEngine(config)
-	if err != nil {
-		// Check if it's a browser not found error
-		var browserNotFoundErr *ezpwErrors.BrowserNotFoundError
-		if errors.As(err, &browserNotFoundErr) {
-			if autoInstall && offerBrowserInstallation(browserNotFoundErr.Browser, verbose) {
-				// Retry after installation
-				engine, err = executor.NewEngine(config)
-				if err != nil {
-					return fmt.Errorf("failed to create engine after browser installation: %w", err)
-				}
-			} else {
-				return fmt.Errorf("browser installation required: %w", err)
-			}
-		} else {
-			return fmt.Errorf("failed to create engine: %w", err)
-		}
-	}
-	defer engine.Close()
-
-	err = engine.Execute(scenario)
 	if err != nil {
-		return fmt.Errorf("failed to execute scenario: %w", err)
+		return nil, err
 	}
 
-	fmt.Printf("✓ Successfully executed: %s\n", filePath)
-	return nil
+	return files, nil
 }
 
 // offerBrowserInstallation offers to install missing browsers
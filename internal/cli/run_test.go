@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -141,12 +142,38 @@ func TestRunCommand_FlagParsing(t *testing.T) {
 	}
 }
 
-func TestProcessPath_NonexistentFile(t *testing.T) {
-	config := testConfig()
+func TestRunCommand_InvalidRecordMode(t *testing.T) {
+	for _, flag := range []string{"trace", "video"} {
+		cmd := &cobra.Command{Use: "run", RunE: RunCommand, SilenceUsage: true, SilenceErrors: true}
+		cmd.Flags().IntP("parallel", "p", 1, "Number of parallel executions")
+		cmd.Flags().String("trace", "off", "Trace mode")
+		cmd.Flags().String("video", "off", "Video mode")
+		cmd.SetArgs([]string{"--" + flag, "always", "scenario.yml"})
+
+		err := cmd.Execute()
+		expected := "--" + flag + ` must be one of: on, off, retain-on-failure, got "always"`
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected invalid %s mode error, got %v", flag, err)
+		}
+	}
+}
+
+func TestRunCommand_NegativeRetries(t *testing.T) {
+	cmd := &cobra.Command{Use: "run", RunE: RunCommand, SilenceUsage: true, SilenceErrors: true}
+	cmd.Flags().IntP("parallel", "p", 1, "Number of parallel executions")
+	cmd.Flags().Int("retries", 0, "Retries")
+	cmd.SetArgs([]string{"--retries", "-1", "scenario.yml"})
+
+	err := cmd.Execute()
+	if err == nil || err.Error() != "--retries must not be negative, got -1" {
+		t.Errorf("Expected negative retries error, got %v", err)
+	}
+}
 
-	err := processPath("/nonexistent/file.yml", config, false, false, false)
+func TestCollectFiles_NonexistentPath(t *testing.T) {
+	_, err := collectFiles("/nonexistent/file.yml", false)
 	if err == nil {
-		t.Error("Expected error for nonexistent file, got nil")
+		t.Fatal("Expected error for nonexistent file, got nil")
 	}
 
 	if !strings.Contains(err.Error(), "path does not exist") {
@@ -154,26 +181,17 @@ func TestProcessPath_NonexistentFile(t *testing.T) {
 	}
 }
 
-func TestProcessPath_InvalidYAMLFile(t *testing.T) {
-	// Create a temporary invalid YAML file
-	tmpFile, err := os.CreateTemp("", "invalid_*.yml")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-	defer os.Remove(tmpFile.Name())
-
-	// Write invalid YAML content
-	_, err = tmpFile.WriteString("invalid: yaml: content: [")
-	if err != nil {
-		t.Fatalf("Failed to write to temp file: %v", err)
+func TestRunner_InvalidYAMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.yml")
+	if err := os.WriteFile(path, []byte("invalid: yaml: content: ["), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
 	}
-	tmpFile.Close()
-
-	config := testConfig()
 
-	err = processPath(tmpFile.Name(), config, false, false, false)
+	r := newRunner(testConfig(), 1, false, false)
+	r.out = &bytes.Buffer{}
+	err := r.run([]string{path})
 	if err == nil {
-		t.Error("Expected error for invalid YAML file, got nil")
+		t.Fatal("Expected error for invalid YAML file, got nil")
 	}
 
 	if !strings.Contains(err.Error(), "failed to parse YAML") {
@@ -181,43 +199,34 @@ func TestProcessPath_InvalidYAMLFile(t *testing.T) {
 	}
 }
 
-func TestProcessDirectory(t *testing.T) {
-	// Create a temporary directory with test files
-	tmpDir, err := os.MkdirTemp("", "test_dir_*")
-	if err != nil {
-		t.Fatalf("Failed to create temp directory: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
+func TestRunner_Directory(t *testing.T) {
+	tmpDir := t.TempDir()
 
-	// Create test YAML files
 	validYAML := `desc: Test scenario
 steps:
   - goto: "https://example.com"`
-
-	yamlFile1 := filepath.Join(tmpDir, "test1.yml")
-	err = os.WriteFile(yamlFile1, []byte(validYAML), 0o600)
-	if err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
-	}
-
-	yamlFile2 := filepath.Join(tmpDir, "test2.yaml")
-	err = os.WriteFile(yamlFile2, []byte(validYAML), 0o600)
-	if err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
+	for _, name := range []string{"test1.yml", "test2.yaml"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(validYAML), 0o600); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
 	}
 
 	// Create a non-YAML file (should be ignored)
-	txtFile := filepath.Join(tmpDir, "readme.txt")
-	err = os.WriteFile(txtFile, []byte("This is not YAML"), 0o600)
-	if err != nil {
+	if err := os.WriteFile(filepath.Join(tmpDir, "readme.txt"), []byte("This is not YAML"), 0o600); err != nil {
 		t.Fatalf("Failed to create text file: %v", err)
 	}
 
-	config := testConfig()
-
-	// Process the directory - this should succeed since browsers are installed
-	err = processPath(tmpDir, config, false, false, false)
+	files, err := collectFiles(tmpDir, false)
 	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 scenario files, got %v", files)
+	}
+
+	r := newRunner(testConfig(), 1, false, false)
+	r.out = &bytes.Buffer{}
+	if err := r.run(files); err != nil {
 		// If browsers are not installed, we expect a browser-related error, not YAML parsing error
 		if strings.Contains(err.Error(), "failed to parse YAML") {
 			t.Errorf("Unexpected YAML parsing error: %v", err)
@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	ezpwErrors "github.com/haruotsu/ezpw/internal/errors"
+	"github.com/haruotsu/ezpw/internal/executor"
+	"github.com/haruotsu/ezpw/internal/playwright"
+	"github.com/haruotsu/ezpw/internal/report"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+// runner executes scenario files on a pool of workers that share a single
+// Playwright driver and browser. Each scenario runs in its own browser context.
+type runner struct {
+	browser     browser.Browser
+	browserErr  error
+	out         io.Writer
+	config      types.Config
+	parallel    int
+	mu          sync.Mutex
+	verbose     bool
+	autoInstall bool
+	failFast    bool   // Skip the remaining files after the first failure
+	root        string // Common directory of the files, naming their artifacts
+}
+
+// fileResult holds the buffered output and outcome of a single scenario file
+type fileResult struct {
+	result *types.ScenarioResult
+	done   chan struct{}
+	output bytes.Buffer
+}
+
+// newRunner creates a runner executing up to parallel files at once
+func newRunner(config types.Config, parallel int, verbose, autoInstall bool) *runner {
+	if parallel < 1 {
+		parallel = 1
+	}
+	return &runner{
+		config:      config,
+		parallel:    parallel,
+		verbose:     verbose,
+		autoInstall: autoInstall,
+		out:         os.Stdout,
+	}
+}
+
+// run executes the given files, followed by a summary of every scenario, and
+// reports an error naming the first failure in file order when any scenario
+// failed. Every file is parsed and validated before any browser is launched;
+// invalid files fail without running. Output of each file is buffered and
+// written as one block in file order, so logs of concurrently running
+// scenarios never interleave. When an output directory is configured, JUnit
+// and JSON reports are written there.
+func (r *runner) run(files []string) error {
+	defer r.close()
+
+	startedAt := time.Now()
+	r.root = commonDir(files)
+
+	results := make([]*fileResult, len(files))
+	for i := range results {
+		results[i] = &fileResult{done: make(chan struct{})}
+	}
+
+	var failed atomic.Bool
+	scenarios := make([]*types.Scenario, len(files))
+	for i, file := range files {
+		res := results[i]
+		start := time.Now()
+		scenario, err := r.parseFile(file, &res.output)
+		if err != nil {
+			fmt.Fprintf(&res.output, "✗ Failed: %s: %v\n", file, err)
+			res.result = &types.ScenarioResult{
+				File:     file,
+				Status:   types.StatusFailed,
+				Err:      err,
+				Duration: time.Since(start),
+			}
+			failed.Store(true)
+			close(res.done)
+			continue
+		}
+		scenarios[i] = scenario
+	}
+
+	workers := r.parallel
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := results[i]
+				// With fail-fast, skip the files not yet started once a
+				// scenario has failed. Checking here rather than when handing
+				// out the job sees the failure of the file this worker just ran.
+				if r.failFast && failed.Load() {
+					res.result = &types.ScenarioResult{File: files[i], Status: types.StatusSkipped}
+					close(res.done)
+					continue
+				}
+				res.result = r.runFile(files[i], scenarios[i], &res.output)
+				if res.result.Failed() {
+					failed.Store(true)
+				}
+				close(res.done)
+			}
+		}()
+	}
+
+	go func() {
+		for i := range files {
+			if scenarios[i] != nil {
+				jobs <- i
+			}
+		}
+		close(jobs)
+	}()
+
+	var firstErr error
+	scenarioResults := make([]*types.ScenarioResult, 0, len(results))
+	failures := 0
+	for i, res := range results {
+		<-res.done
+		_, _ = r.out.Write(res.output.Bytes())
+		if res.result.Failed() {
+			failures++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("first failure in %s: %w", files[i], res.result.Err)
+			}
+		}
+		scenarioResults = append(scenarioResults, res.result)
+	}
+	wg.Wait()
+
+	writeSummary(r.out, scenarioResults, time.Since(startedAt))
+
+	if r.config.OutputDir != "" {
+		if err := report.Write(r.config.OutputDir, startedAt, scenarioResults); err != nil {
+			return err
+		}
+		if r.verbose {
+			fmt.Fprintf(r.out, "Reports written to: %s\n", r.config.OutputDir)
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d scenario(s) failed; %w", failures, len(files), firstErr)
+	}
+	return nil
+}
+
+// commonDir returns the deepest directory containing every file, or an empty
+// string when there is none
+func commonDir(files []string) string {
+	var common []string
+	for i, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return ""
+		}
+		dir := strings.Split(filepath.Dir(abs), string(filepath.Separator))
+		if i == 0 {
+			common = dir
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	if len(common) == 0 {
+		return ""
+	}
+	if len(common) == 1 {
+		// Only the volume or the empty element before the leading separator
+		return common[0] + string(filepath.Separator)
+	}
+	return strings.Join(common, string(filepath.Separator))
+}
+
+// writeSummary writes a table of the status and duration of every scenario,
+// followed by the totals
+func writeSummary(w io.Writer, results []*types.ScenarioResult, elapsed time.Duration) {
+	fmt.Fprintln(w)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "STATUS\tDURATION\tSCENARIO\tFILE")
+	for _, result := range results {
+		duration := "-"
+		if result.Status != types.StatusSkipped {
+			duration = formatDuration(result.Duration)
+		}
+		fmt.Fprintf(table, "%s %s\t%s\t%s\t%s\n",
+			statusSymbols[result.Status], result.Status, duration, result.Name, result.File)
+	}
+	_ = table.Flush()
+
+	summary := report.Summarize(results)
+	fmt.Fprintf(w, "\n%d scenario(s): %d passed, %d flaky, %d failed, %d skipped in %s\n", summary.Total,
+		summary.Passed, summary.Flaky, summary.Failed, summary.Skipped, formatDuration(elapsed))
+}
+
+var statusSymbols = map[string]string{
+	types.StatusPassed:  "✓",
+	types.StatusFlaky:   "~",
+	types.StatusFailed:  "✗",
+	types.StatusSkipped: "-",
+}
+
+// formatDuration rounds a duration for display, e.g. "1.25s"
+func formatDuration(d time.Duration) string {
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// runFile executes the scenario parsed from a file, writing progress to out
+func (r *runner) runFile(filePath string, scenario *types.Scenario, out io.Writer) *types.ScenarioResult {
+	start := time.Now()
+
+	b, err := r.sharedBrowser()
+	if err != nil {
+		fmt.Fprintf(out, "✗ Failed: %s: %v\n", filePath, err)
+		return &types.ScenarioResult{
+			Name:     scenario.Description,
+			File:     filePath,
+			Status:   types.StatusFailed,
+			Err:      err,
+			Duration: time.Since(start),
+		}
+	}
+
+	engine := executor.NewEngineWithBrowser(b, r.config)
+	engine.SetOutput(out)
+	engine.SetArtifactRoot(r.root)
+	defer engine.Close()
+
+	result := engine.Run(scenario)
+	result.File = filePath
+	if result.Err != nil {
+		result.Err = fmt.Errorf("failed to execute scenario: %w", result.Err)
+		fmt.Fprintf(out, "✗ Failed: %s: %v\n", filePath, result.Err)
+		return result
+	}
+
+	if result.Status == types.StatusFlaky {
+		fmt.Fprintf(out, "~ Flaky, passed on attempt %d: %s\n", result.Attempts, filePath)
+		return result
+	}
+	fmt.Fprintf(out, "✓ Successfully executed: %s\n", filePath)
+	return result
+}
+
+// parseFile reads, parses and validates a single scenario file
+func (r *runner) parseFile(filePath string, out io.Writer) (*types.Scenario, error) {
+	if r.verbose {
+		fmt.Fprintf(out, "Processing file: %s\n", filePath)
+	}
+
+	// Parse errors already name the file and say what failed
+	scenario, errs := loadScenario(filePath)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if r.verbose {
+		fmt.Fprintf(out, "Parsed scenario: %s with %d steps\n", scenario.Description, len(scenario.Steps))
+	}
+
+	return scenario, nil
+}
+
+// sharedBrowser launches the browser on first use and returns it to every worker.
+// Launching lazily means a run whose files all fail validation never starts
+// Playwright.
+func (r *runner) sharedBrowser() (browser.Browser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.browser == nil && r.browserErr == nil {
+		r.browser, r.browserErr = r.launchBrowser()
+	}
+	return r.browser, r.browserErr
+}
+
+// launchBrowser starts the browser, offering to install it when missing
+func (r *runner) launchBrowser() (browser.Browser, error) {
+	b, err := playwright.NewBrowser(r.config)
+	if err == nil {
+		return b, nil
+	}
+
+	// Check if it's a browser not found error
+	var browserNotFoundErr *ezpwErrors.BrowserNotFoundError
+	if !errors.As(err, &browserNotFoundErr) {
+		return nil, fmt.Errorf("failed to create engine: %w", err)
+	}
+
+	if !r.autoInstall || !offerBrowserInstallation(browserNotFoundErr.Browser, r.verbose) {
+		return nil, fmt.Errorf("browser installation required: %w", err)
+	}
+
+	// Retry after installation
+	b, err = playwright.NewBrowser(r.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine after browser installation: %w", err)
+	}
+	return b, nil
+}
+
+// close shuts down the shared browser if it was launched
+func (r *runner) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.browser != nil {
+		if err := r.browser.Close(); err != nil {
+			fmt.Fprintf(r.out, "Warning: %v\n", err)
+		}
+		r.browser = nil
+	}
+}
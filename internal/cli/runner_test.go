@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestCollectFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, name := range []string{"b.yml", "a.yaml", "notes.txt", "nested/c.yml"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("desc: test"), 0o600); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	files, err := collectFiles(tmpDir, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tmpDir, "a.yaml"),
+		filepath.Join(tmpDir, "b.yml"),
+		filepath.Join(tmpDir, "nested", "c.yml"),
+	}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected files %v, got %v", expected, files)
+	}
+}
+
+func TestRunner_OutputIsBufferedInFileOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Files that fail to parse never launch a browser, so this runs anywhere
+	var files []string
+	for i := 0; i < 8; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("invalid_%d.yml", i))
+		if err := os.WriteFile(path, []byte("invalid: yaml: content: ["), 0o600); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	var out bytes.Buffer
+	r := newRunner(testConfig(), 4, true, false)
+	r.out = &out
+
+	err := r.run(files)
+	if err == nil || !strings.HasPrefix(err.Error(), "8 of 8 scenario(s) failed; first failure in "+files[0]+": ") {
+		t.Fatalf("Expected every invalid file to fail, got: %v", err)
+	}
+
+	// Every file must appear as a contiguous block in file order, before the summary
+	summary := strings.Index(out.String(), "STATUS")
+	if summary < 0 {
+		t.Fatalf("Expected a summary, got:\n%s", out.String())
+	}
+	last := -1
+	lines := strings.Split(strings.TrimSpace(out.String()[:summary]), "\n")
+	for i := 0; i+1 < len(lines); i += 2 {
+		var index int
+		if _, err := fmt.Sscanf(filepath.Base(strings.TrimPrefix(lines[i], "Processing file: ")), "invalid_%d.yml", &index); err != nil {
+			t.Fatalf("Unexpected output line %q", lines[i])
+		}
+		if !strings.HasPrefix(lines[i+1], "✗ Failed: "+files[index]+": ") {
+			t.Errorf("Expected failure of file %d after its progress, got %q", index, lines[i+1])
+		}
+		if index <= last {
+			t.Errorf("Output for file %d printed after file %d", index, last)
+		}
+		last = index
+	}
+	if last != len(files)-1 {
+		t.Errorf("Expected output for all %d files, last was file %d", len(files), last)
+	}
+}
+
+func TestRunner_FailFast(t *testing.T) {
+	// Nothing listens on port 1, so every valid file fails when it runs, or
+	// when the browser cannot be launched
+	valid := "steps:\n  - goto: http://127.0.0.1:1\n"
+	invalid := "invalid: yaml: content: ["
+	tests := []struct {
+		name     string
+		contents []string
+	}{
+		{name: "failure while running", contents: []string{valid, valid, valid}},
+		{name: "invalid file", contents: []string{invalid, valid, valid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			var files []string
+			for i, content := range tt.contents {
+				path := filepath.Join(tmpDir, fmt.Sprintf("scenario_%d.yml", i))
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+				files = append(files, path)
+			}
+
+			config := testConfig()
+			config.Timeout = 1000
+			var out bytes.Buffer
+			r := newRunner(config, 1, false, false)
+			r.out = &out
+			r.failFast = true
+
+			if err := r.run(files); err == nil || !strings.HasPrefix(err.Error(), "1 of 3 scenario(s) failed; first failure in "+files[0]) {
+				t.Errorf("Expected only the first file to fail, got: %v", err)
+			}
+			if !strings.Contains(out.String(), "3 scenario(s): 0 passed, 0 flaky, 1 failed, 2 skipped") {
+				t.Errorf("Expected the remaining files to be skipped, got:\n%s", out.String())
+			}
+			for _, file := range files[1:] {
+				if strings.Contains(out.String(), "✗ Failed: "+file) {
+					t.Errorf("Expected %s not to run after the first failure, got:\n%s", file, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_ValidatesBeforeRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.yml")
+	content := "steps:\n  - assert:\n      type: text_contnet\n      selector: h1\n      contains: Hi\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	r := newRunner(testConfig(), 1, false, false)
+	r.out = &out
+	err := r.run([]string{path})
+	if err == nil || !strings.Contains(err.Error(), `unknown assertion type: text_contnet (did you mean "text_content"?)`) {
+		t.Errorf("Expected the unknown assertion type to be reported, got: %v", err)
+	}
+	if r.browser != nil || r.browserErr != nil {
+		t.Error("Expected no browser to be launched for an invalid file")
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "no files", expected: ""},
+		{name: "single file", files: []string{filepath.Join(root, "login.yml")}, expected: root},
+		{
+			name:     "nested directories",
+			files:    []string{filepath.Join(root, "a", "login.yml"), filepath.Join(root, "b", "c", "login.yml")},
+			expected: root,
+		},
+		{
+			name:     "same directory",
+			files:    []string{filepath.Join(root, "a", "login.yml"), filepath.Join(root, "a", "cart.yml")},
+			expected: filepath.Join(root, "a"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := commonDir(tt.files); actual != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	results := []*types.ScenarioResult{
+		{File: "login.yml", Name: "Login", Status: types.StatusPassed, Duration: 1234 * time.Millisecond},
+		{File: "checkout.yml", Name: "Checkout", Status: types.StatusFailed, Duration: 500 * time.Millisecond},
+		{File: "cart.yml", Name: "Cart", Status: types.StatusFlaky, Duration: 3 * time.Second, Attempts: 2},
+		{File: "search.yml", Status: types.StatusSkipped},
+	}
+
+	var out bytes.Buffer
+	writeSummary(&out, results, 2*time.Second)
+
+	expected := `
+STATUS     DURATION  SCENARIO  FILE
+✓ passed   1.23s     Login     login.yml
+✗ failed   500ms     Checkout  checkout.yml
+~ flaky    3s        Cart      cart.yml
+- skipped  -                   search.yml
+
+4 scenario(s): 1 passed, 1 flaky, 1 failed, 1 skipped in 2s
+`
+	if out.String() != expected {
+		t.Errorf("Expected summary:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestRunner_WritesReports(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	path := filepath.Join(tmpDir, "invalid.yml")
+	if err := os.WriteFile(path, []byte("invalid: yaml: content: ["), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := testConfig()
+	config.OutputDir = filepath.Join(tmpDir, "reports")
+
+	r := newRunner(config, 1, false, false)
+	r.out = &bytes.Buffer{}
+	if err := r.run([]string{path}); err == nil {
+		t.Fatal("Expected error for invalid YAML file, got nil")
+	}
+
+	content, err := os.ReadFile(filepath.Join(config.OutputDir, "junit.xml"))
+	if err != nil {
+		t.Fatalf("Expected JUnit report to be written: %v", err)
+	}
+	if !strings.Contains(string(content), path+": failed to parse YAML") || strings.Contains(string(content), "failed to parse YAML: "+path) {
+		t.Errorf("Expected parse failure without a repeated prefix in JUnit report, got:\n%s", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "results.json")); err != nil {
+		t.Errorf("Expected JSON report to be written: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/haruotsu/ezpw/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+// SchemaCommand handles the schema command logic. It writes the JSON Schema
+// of the scenario file format to stdout or to the file given by --output.
+func SchemaCommand(cmd *cobra.Command, _ []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		return schema.Write(cmd.OutOrStdout())
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer f.Close()
+
+	if err := schema.Write(f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/haruotsu/ezpw/internal/executor"
+	"github.com/haruotsu/ezpw/internal/parser"
+	"github.com/haruotsu/ezpw/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// ValidateCommand handles the validate command logic. It parses and checks
+// scenario files without launching a browser and reports every problem found.
+func ValidateCommand(cmd *cobra.Command, args []string) error {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	out := cmd.OutOrStdout()
+
+	var files []string
+	problems := 0
+	for _, arg := range args {
+		found, err := collectFiles(arg, false)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", arg, err)
+			problems++
+			continue
+		}
+		files = append(files, found...)
+	}
+
+	invalidFiles := 0
+	for _, file := range files {
+		_, errs := loadScenario(file)
+		for _, err := range errs {
+			fmt.Fprintln(out, err)
+		}
+		if len(errs) > 0 {
+			invalidFiles++
+			problems += len(errs)
+		} else if verbose {
+			fmt.Fprintf(out, "✓ %s\n", file)
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problem(s) in %d of %d file(s)", problems, invalidFiles, len(files))
+	}
+	fmt.Fprintf(out, "✓ %d file(s) valid\n", len(files))
+	return nil
+}
+
+// loadScenario parses a scenario file and checks that the engine can run
+// every step. It returns the scenario, or every problem found in the file:
+// the steps that parsed are checked even when others did not.
+func loadScenario(path string) (*types.Scenario, []error) {
+	scenario, err := parser.ParseFile(path)
+	var errs []error
+	if err != nil {
+		var list parser.ErrorList
+		var parseErr *parser.ParseError
+		switch {
+		case errors.As(err, &list):
+			errs = list.Unwrap()
+		case errors.As(err, &parseErr):
+			return nil, []error{err}
+		default:
+			return nil, []error{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+
+	if scenario != nil {
+		errs = append(errs, executor.Validate(scenario)...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return scenario, nil
+}
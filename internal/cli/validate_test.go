@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newValidateCommand returns a validate command writing into out
+func newValidateCommand(out *bytes.Buffer, args ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "validate",
+		RunE:         ValidateCommand,
+		SilenceUsage: true,
+	}
+	cmd.Flags().BoolP("verbose", "v", false, "List valid files too")
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs(args)
+	return cmd
+}
+
+func TestValidateCommand_ReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.yml": "desc: Valid\nsteps:\n  - goto: \"https://example.com\"\n",
+		"typo.yml": `desc: Typo
+steps:
+  - click:
+      selecter: "#submit"
+  - fill:
+      selector: "#name"
+`,
+		"assert.yml": `desc: Unknown assertion
+steps:
+  - assert:
+      type: visible_text
+      selector: h1
+`,
+		"broken.yml": "steps: [\n",
+		"mixed.yml": `desc: Parse and engine problems
+steps:
+  - clik:
+      selector: "#a"
+  - assert:
+      type: text_content
+      selector: h1
+      contains: Hi
+      equals: Hi
+  - assert:
+      type: text_contnet
+      selector: h1
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := newValidateCommand(&out, dir).Execute()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "found 8 problem(s) in 4 of 5 file(s)") {
+		t.Errorf("Unexpected error summary: %v", err)
+	}
+
+	expected := []string{
+		`assert.yml:3:5: assert visible_text "h1": unknown assertion type: visible_text`,
+		"broken.yml:1: failed to parse YAML",
+		`typo.yml:4:7: unknown key "selecter" in click step (did you mean "selector"?)`,
+		"typo.yml:4:7: click step requires selector",
+		"typo.yml:6:7: fill step requires value",
+		`mixed.yml:3:5: no valid step type found in step: unknown key "clik" (did you mean "click"?)`,
+		`mixed.yml:5:5: assert text_content "h1": text_content assertion requires exactly one of: contains, equals, matches, starts_with, ends_with`,
+		`mixed.yml:10:5: assert text_contnet "h1": unknown assertion type: text_contnet (did you mean "text_content"?)`,
+	}
+	output := out.String()
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestValidateCommand_ValidFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "valid.yml")
+	content := "desc: Valid\nsteps:\n  - goto: \"https://example.com\"\n  - assert:\n      type: url\n      contains: example\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := newValidateCommand(&out, "--verbose", path).Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "✓ "+path) || !strings.Contains(out.String(), "1 file(s) valid") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestValidateCommand_MissingPath(t *testing.T) {
+	var out bytes.Buffer
+	err := newValidateCommand(&out, "/nonexistent/dir").Execute()
+	if err == nil {
+		t.Fatal("Expected error for missing path, got nil")
+	}
+	if !strings.Contains(out.String(), "path does not exist") {
+		t.Errorf("Expected missing path to be reported, got:\n%s", out.String())
+	}
+}
@@ -1,125 +1,959 @@
 package executor
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/haruotsu/ezpw/internal/browser"
 	"github.com/haruotsu/ezpw/internal/playwright"
+	"github.com/haruotsu/ezpw/internal/vars"
 	"github.com/haruotsu/ezpw/pkg/types"
 )
 
 // Engine executes test scenarios
 type Engine struct {
-	browser   browser.Browser
-	page      browser.Page
-	assertion *playwright.Assertion
-	config    types.Config
+	browser     browser.Browser
+	page        browser.Page
+	assertion   *playwright.Assertion
+	out         io.Writer
+	vars        map[string]string
+	config      types.Config
+	timeout     int       // Timeout of the running step in milliseconds, 0 for none
+	deadline    time.Time // Deadline of the running steps, zero for none
+	limit       string    // What sets deadline, e.g. "scenario deadline"
+	ownsBrowser bool
+
+	// Whether every assertion of the running scenario is soft, and the soft
+	// assertion failures recorded so far, including those in nested steps.
+	// Within a retry_until attempt every assertion is hard.
+	softAssertions   bool
+	softFailures     []error
+	strictAssertions bool
+
+	// Naming and bookkeeping of the files saved while running a scenario
+	artifactRoot   string // Directory scenario files are named relative to
+	artifactPrefix string
+	stepIndex      int
+	artifacts      []string
 }
 
-// NewEngine creates a new execution engine
+// captureTimeout bounds the failure screenshot, which may run after the
+// scenario deadline has passed
+const captureTimeout = 5000
+
+// Defaults of retry_until steps
+const (
+	defaultMaxAttempts   = 3
+	defaultRetryInterval = 1000 // milliseconds
+)
+
+// errConditionNotMet is returned by a step whose if or unless condition tells
+// it not to run. The step is skipped rather than failed.
+var errConditionNotMet = errors.New("condition not met")
+
+// NewEngine creates a new execution engine with its own browser
 func NewEngine(config types.Config) (*Engine, error) {
 	browser, err := playwright.NewBrowser(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create browser: %w", err)
 	}
 
-	page, err := browser.NewPage()
-	if err != nil {
-		browser.Close()
-		return nil, fmt.Errorf("failed to create page: %w", err)
+	engine := NewEngineWithBrowser(browser, config)
+	engine.ownsBrowser = true
+	return engine, nil
+}
+
+// NewEngineWithBrowser creates an execution engine on top of a shared browser.
+// The browser is not closed when the engine is closed.
+func NewEngineWithBrowser(browser browser.Browser, config types.Config) *Engine {
+	return &Engine{
+		config:  config,
+		browser: browser,
+		out:     os.Stdout,
 	}
+}
 
-	assertion := playwright.NewAssertion(page)
+// SetOutput sets the writer that receives progress messages
+func (e *Engine) SetOutput(w io.Writer) {
+	e.out = w
+}
 
-	return &Engine{
-		config:    config,
-		browser:   browser,
-		page:      page,
-		assertion: assertion,
-	}, nil
+// SetArtifactRoot names the files saved for a scenario after the path of its
+// file relative to dir, so scenario files with the same name in different
+// directories do not overwrite each other's files
+func (e *Engine) SetArtifactRoot(dir string) {
+	e.artifactRoot = dir
 }
 
-// Execute runs a test scenario
+// Execute runs a test scenario in a fresh page with an isolated browser context
 func (e *Engine) Execute(scenario *types.Scenario) error {
-	fmt.Printf("Executing scenario: %s\n", scenario.Description)
+	return e.Run(scenario).Err
+}
+
+// Run executes a test scenario and returns the result of the scenario and
+// each of its steps. Steps after the first failure are reported as skipped,
+// except that failed soft assertions let the scenario continue; they are
+// reported together at the end. A failed scenario is rerun in a fresh page up
+// to its number of retries; one that passes on a retry is reported as flaky
+// with the steps of its last run.
+func (e *Engine) Run(scenario *types.Scenario) *types.ScenarioResult {
+	start := time.Now()
+	retries := e.retries(scenario)
+
+	var artifacts []string
+	var retriedErrs []error
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			fmt.Fprintf(e.out, "Retrying scenario (attempt %d of %d): %s\n", attempt, retries+1, scenario.Description)
+		}
+
+		result := &types.ScenarioResult{
+			Name:   scenario.Description,
+			Status: types.StatusPassed,
+		}
+		e.run(scenario, result, attempt)
+		artifacts = append(artifacts, result.Artifacts...)
+
+		if result.Err == nil || attempt > retries {
+			result.Duration = time.Since(start)
+			result.Attempts = attempt
+			result.RetriedErrs = retriedErrs
+			result.Artifacts = artifacts
+			switch {
+			case result.Err != nil:
+				result.Status = types.StatusFailed
+			case attempt > 1:
+				result.Status = types.StatusFlaky
+			}
+			return result
+		}
+		retriedErrs = append(retriedErrs, result.Err)
+	}
+}
+
+// retries returns how many times a failed scenario is rerun. The scenario's
+// own setting takes precedence over the configured default.
+func (e *Engine) retries(scenario *types.Scenario) int {
+	if scenario.Retries != nil {
+		return *scenario.Retries
+	}
+	return e.config.Retries
+}
+
+// run executes the scenario steps once, recording the outcome into result.
+// Files saved by a retry are named after the attempt so earlier ones are kept.
+func (e *Engine) run(scenario *types.Scenario, result *types.ScenarioResult, attempt int) {
+	fmt.Fprintf(e.out, "Executing scenario: %s\n", scenario.Description)
+
+	videoMode := e.videoMode(scenario)
+	page, err := e.browser.NewPageWith(browser.PageOptions{RecordVideo: recording(videoMode)})
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create page: %w", err)
+		return
+	}
+	e.page = page
+	e.assertion = playwright.NewAssertion(page)
+	e.artifactPrefix = artifactPrefix(scenario, e.artifactRoot)
+	if attempt > 1 {
+		e.artifactPrefix += fmt.Sprintf("-retry%d", attempt-1)
+	}
+	e.artifacts = nil
+	defer e.finishScenario(result, videoMode)
+
+	if err := e.initVars(scenario); err != nil {
+		result.Err = err
+		return
+	}
+
+	scenarioTimeout := e.scenarioTimeout(scenario)
+	var deadline time.Time
+	if scenarioTimeout > 0 {
+		deadline = time.Now().Add(time.Duration(scenarioTimeout) * time.Millisecond)
+	}
+	e.deadline, e.limit = deadline, "scenario deadline"
 
+	e.softAssertions, e.softFailures = scenario.SoftAssertions, nil
 	for i, step := range scenario.Steps {
-		fmt.Printf("Step %d: %s\n", i+1, step.Type)
+		stepResult := types.StepResult{
+			Index:  i + 1,
+			Type:   step.Type,
+			Pos:    step.Pos,
+			Status: types.StatusSkipped,
+		}
+		if result.Err != nil {
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+
+		fmt.Fprintf(e.out, "Step %d: %s\n", i+1, step.Type)
+		e.stepIndex = i + 1
+
+		stepStart := time.Now()
+		softCount := len(e.softFailures)
+		err := e.applyStepTimeout(&step)
+		if err == nil {
+			err = e.executeInterpolated(&step)
+		}
+		timedOut := !deadline.IsZero() && !time.Now().Before(deadline)
+		if err != nil && timedOut {
+			err = fmt.Errorf("scenario timeout of %dms exceeded: %w", scenarioTimeout, err)
+		}
+		stepResult.Duration = time.Since(stepStart)
+		stepResult.Status = types.StatusPassed
+		if errors.Is(err, errConditionNotMet) {
+			stepResult.Status = types.StatusSkipped
+			err = nil
+		}
+		if err != nil {
+			stepResult.Status = types.StatusFailed
+			stepResult.Err = err
+			e.captureFailure()
+			failure := stepFailure(i, &step, err)
+			if e.isSoft(&step) && !timedOut {
+				e.recordSoftFailure(failure)
+			} else {
+				result.Err = failure
+			}
+		} else if nested := e.softFailures[softCount:]; len(nested) > 0 {
+			// Soft assertions failed inside a block that otherwise passed
+			stepResult.Status = types.StatusFailed
+			stepResult.Err = errors.Join(nested...)
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+
+	// Report every soft assertion failure, followed by the failure that
+	// stopped the scenario, if any
+	if len(e.softFailures) > 0 {
+		result.Err = errors.Join(append(e.softFailures, result.Err)...)
+	}
+
+	if result.Err == nil {
+		fmt.Fprintln(e.out, "Scenario completed successfully")
+	}
+}
+
+// isSoft reports whether a failure of step lets the scenario continue
+func (e *Engine) isSoft(step *types.Step) bool {
+	return !e.strictAssertions && step.Type == "assert" && (step.Soft || e.softAssertions)
+}
+
+// recordSoftFailure keeps a soft assertion failure to report when the
+// scenario ends
+func (e *Engine) recordSoftFailure(failure error) {
+	fmt.Fprintf(e.out, "Soft assertion failed: %v\n", failure)
+	e.softFailures = append(e.softFailures, failure)
+}
 
-		err := e.executeStep(&step)
+// initVars builds the variables visible to a scenario. Command line variables
+// override scenario variables; OS environment variables are used as a fallback.
+// Scenario variable values may themselves reference command line and
+// environment variables.
+func (e *Engine) initVars(scenario *types.Scenario) error {
+	e.vars = make(map[string]string, len(scenario.Vars)+len(e.config.Vars))
+
+	lookupOuter := func(name string) (string, bool) {
+		if value, ok := e.config.Vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+	for name, value := range scenario.Vars {
+		expanded, err := vars.Expand(value, lookupOuter)
 		if err != nil {
-			return fmt.Errorf("step %d failed: %w", i+1, err)
+			return fmt.Errorf("failed to expand variable %s: %w", name, err)
+		}
+		e.vars[name] = expanded
+	}
+
+	for name, value := range e.config.Vars {
+		e.vars[name] = value
+	}
+	return nil
+}
+
+// lookupVar resolves a variable by name for ${NAME} expansion
+func (e *Engine) lookupVar(name string) (string, bool) {
+	if value, ok := e.vars[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// executeInterpolated expands ${NAME} references in the step and executes it
+func (e *Engine) executeInterpolated(step *types.Step) error {
+	resolved, err := step.MapStrings(func(value string) (string, error) {
+		return vars.Expand(value, e.lookupVar)
+	})
+	if err != nil {
+		return err
+	}
+	return e.executeStep(&resolved)
+}
+
+// stepFailure describes a failed step by its source location when known,
+// e.g. `login.yml:14:5: click "#submit": timeout`
+func stepFailure(index int, step *types.Step, err error) error {
+	if step.Pos.IsValid() {
+		return fmt.Errorf("%s: %s: %w", step.Pos, step.Describe(), err)
+	}
+	return fmt.Errorf("step %d failed: %w", index+1, err)
+}
+
+// scenarioTimeout returns the overall deadline of a scenario in milliseconds.
+// The scenario's own timeout takes precedence over the configured default.
+func (e *Engine) scenarioTimeout(scenario *types.Scenario) int {
+	if scenario.Timeout > 0 {
+		return scenario.Timeout
+	}
+	return e.config.ScenarioTimeout
+}
+
+// applyStepTimeout sets the page timeout for a step. The step's own timeout
+// overrides the global one, and both are capped by the deadline of the
+// running steps: the scenario deadline, or the timeout of an enclosing block.
+func (e *Engine) applyStepTimeout(step *types.Step) error {
+	timeout := e.config.Timeout
+	if step.Timeout > 0 {
+		timeout = step.Timeout
+	}
+
+	if !e.deadline.IsZero() {
+		remaining := time.Until(e.deadline).Milliseconds()
+		if remaining <= 0 {
+			return fmt.Errorf("no time left before %s", e.limit)
+		}
+		if timeout == 0 || int64(timeout) > remaining {
+			timeout = int(remaining)
 		}
 	}
 
-	fmt.Println("Scenario completed successfully")
+	e.timeout = timeout
+	e.page.SetTimeout(timeout)
 	return nil
 }
 
+// closePage closes the page opened for the current scenario
+func (e *Engine) closePage() {
+	if e.page == nil {
+		return
+	}
+	if err := e.page.Close(); err != nil {
+		fmt.Fprintf(e.out, "Warning: %v\n", err)
+	}
+	e.page = nil
+	e.assertion = nil
+}
+
 // executeStep executes a single step
 func (e *Engine) executeStep(step *types.Step) error {
+	if err := checkStep(step); err != nil {
+		return err
+	}
+	if step.Type != "if" {
+		holds, err := e.conditionHolds(step)
+		if err != nil {
+			return err
+		}
+		if !holds {
+			fmt.Fprintf(e.out, "Skipping step, %s\n", errConditionNotMet)
+			return errConditionNotMet
+		}
+	}
+
 	switch step.Type {
 	case "goto":
-		if step.URL == "" {
-			return fmt.Errorf("goto step requires URL")
-		}
 		return e.page.Goto(step.URL)
 
-	case "click":
-		if step.Selector == "" {
-			return fmt.Errorf("click step requires selector")
-		}
-		return e.page.Click(step.Selector)
+	case "click", "dblclick", "right_click":
+		return e.executeClick(step)
+
+	case "hover":
+		return e.page.Hover(step.Selector)
+
+	case "drag":
+		return e.page.DragAndDrop(step.Selector, step.Target)
 
 	case "fill":
-		if step.Selector == "" {
-			return fmt.Errorf("fill step requires selector")
-		}
-		if step.Value == "" {
-			return fmt.Errorf("fill step requires value")
-		}
 		return e.page.Fill(step.Selector, step.Value)
 
+	case "select":
+		return e.page.SelectOption(step.Selector, browser.SelectOptions{
+			Values:  step.OptionValues,
+			Labels:  step.OptionLabels,
+			Indexes: step.OptionIndexes,
+		})
+
+	case "check":
+		return e.page.Check(step.Selector)
+
+	case "uncheck":
+		return e.page.Uncheck(step.Selector)
+
+	case "upload":
+		return e.page.SetInputFiles(step.Selector, resolveFiles(step))
+
+	case "press":
+		return e.page.Press(step.Selector, step.Key)
+
+	case "type":
+		return e.page.Type(step.Selector, step.Value, step.Delay)
+
+	case "key_down":
+		return e.page.KeyDown(step.Key)
+
+	case "key_up":
+		return e.page.KeyUp(step.Key)
+
+	case "wait":
+		return e.executeWait(step)
+
+	case "screenshot":
+		return e.executeScreenshot(step)
+
 	case "assert":
 		return e.executeAssert(step)
 
+	case "store":
+		return e.executeStore(step)
+
+	case "retry_until":
+		return e.executeRetryUntil(step)
+
+	case "if":
+		return e.executeIf(step)
+
 	default:
 		return fmt.Errorf("unknown step type: %s", step.Type)
 	}
 }
 
-// executeAssert handles assertion steps
+// executeRetryUntil runs the steps of a retry_until block until all of them
+// pass, up to max_attempts times with interval between attempts. The block's
+// own timeout bounds all attempts together; each nested step keeps its own
+// step timeout. Assertions are hard within an attempt so that a failed one is
+// retried; only when the last attempt fails at a soft assertion is the
+// failure recorded as soft.
+func (e *Engine) executeRetryUntil(step *types.Step) error {
+	maxAttempts := step.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	interval := time.Duration(defaultRetryInterval) * time.Millisecond
+	if step.Interval != nil {
+		interval = time.Duration(*step.Interval) * time.Millisecond
+	}
+
+	strict := e.strictAssertions
+	e.strictAssertions = true
+	var failed *types.Step
+	err := e.withinBlock(step, func() error {
+		var err error
+		attempt := 1
+		for ; ; attempt++ {
+			if failed, err = e.runBlock(step.Steps); err == nil {
+				return nil
+			}
+			if attempt == maxAttempts || (!e.deadline.IsZero() && time.Until(e.deadline) <= interval) {
+				break
+			}
+			fmt.Fprintf(e.out, "Attempt %d of %d failed, retrying in %s: %v\n", attempt, maxAttempts, interval, err)
+			time.Sleep(interval)
+		}
+		return fmt.Errorf("steps did not pass after %d attempt(s): %w", attempt, err)
+	})
+	e.strictAssertions = strict
+
+	timedOut := !e.deadline.IsZero() && !time.Now().Before(e.deadline)
+	if err != nil && failed != nil && e.isSoft(failed) && !timedOut {
+		e.recordSoftFailure(err)
+		return nil
+	}
+	return err
+}
+
+// executeIf runs the then steps of an if block when its condition holds and
+// the else steps otherwise
+func (e *Engine) executeIf(step *types.Step) error {
+	holds, err := e.conditionHolds(step)
+	if err != nil {
+		return err
+	}
+	steps := step.Else
+	if holds {
+		steps = step.Steps
+	}
+	return e.withinBlock(step, func() error {
+		return e.executeBlock(steps)
+	})
+}
+
+// withinBlock calls run with the deadline narrowed to the timeout of a block
+// step when that is earlier, and restores the deadline afterwards
+func (e *Engine) withinBlock(step *types.Step, run func() error) error {
+	deadline, limit := e.deadline, e.limit
+	defer func() { e.deadline, e.limit = deadline, limit }()
+
+	if step.Timeout > 0 {
+		blockDeadline := time.Now().Add(time.Duration(step.Timeout) * time.Millisecond)
+		if deadline.IsZero() || blockDeadline.Before(deadline) {
+			e.deadline = blockDeadline
+			e.limit = fmt.Sprintf("the %dms timeout of the %s step", step.Timeout, step.Type)
+		}
+	}
+	return run()
+}
+
+// conditionHolds reports whether the if condition of a step holds, or its
+// unless condition does not. A step without a condition always runs.
+func (e *Engine) conditionHolds(step *types.Step) (bool, error) {
+	switch {
+	case step.If != nil:
+		return e.evaluate(step.If)
+	case step.Unless != nil:
+		holds, err := e.evaluate(step.Unless)
+		return !holds, err
+	default:
+		return true, nil
+	}
+}
+
+// evaluate checks a condition against the current page and variables once,
+// without waiting for it to become true
+func (e *Engine) evaluate(condition *types.Condition) (bool, error) {
+	switch {
+	case condition.Exists != "":
+		return e.page.ElementExists(condition.Exists)
+	case condition.Visible != "":
+		return e.page.IsVisible(condition.Visible)
+	case condition.URL != "":
+		return strings.Contains(e.page.URL(), condition.URL), nil
+	case condition.URLMatches != "":
+		return regexp.MatchString(condition.URLMatches, e.page.URL())
+	default:
+		value, _ := e.lookupVar(condition.Variable)
+		switch {
+		case condition.Equals != nil:
+			return value == *condition.Equals, nil
+		case condition.NotEquals != nil:
+			return value != *condition.NotEquals, nil
+		default:
+			return value != "", nil
+		}
+	}
+}
+
+// executeBlock runs nested steps in order, stopping at the first failure,
+// and reports the failure located at its step. Steps skipped by their
+// condition do not stop the block, and soft assertion failures are recorded
+// as at the top level, unless the deadline has passed.
+func (e *Engine) executeBlock(steps []types.Step) error {
+	_, err := e.runBlock(steps)
+	return err
+}
+
+// runBlock runs nested steps like executeBlock and also returns the step
+// that failed, if any
+func (e *Engine) runBlock(steps []types.Step) (*types.Step, error) {
+	for i := range steps {
+		step := steps[i]
+		err := e.applyStepTimeout(&step)
+		if err == nil {
+			err = e.executeInterpolated(&step)
+		}
+		if err == nil || errors.Is(err, errConditionNotMet) {
+			continue
+		}
+		timedOut := !e.deadline.IsZero() && !time.Now().Before(e.deadline)
+		if e.isSoft(&step) && !timedOut {
+			e.recordSoftFailure(stepFailure(i, &step, err))
+			continue
+		}
+		return &step, stepFailure(i, &step, err)
+	}
+	return nil, nil
+}
+
+// executeClick handles the click steps. A plain click on a selector keeps
+// using Page.Click; buttons, modifiers and positions go through Page.ClickWith.
+func (e *Engine) executeClick(step *types.Step) error {
+	options := browser.ClickOptions{
+		Button:    step.Button,
+		Modifiers: step.Modifiers,
+		Position:  step.Point,
+	}
+	switch step.Type {
+	case "dblclick":
+		options.ClickCount = 2
+	case "right_click":
+		options.Button = "right"
+	}
+
+	if step.Type == "click" && options.Button == "" && len(options.Modifiers) == 0 && options.Position == nil {
+		return e.page.Click(step.Selector)
+	}
+	return e.page.ClickWith(step.Selector, options)
+}
+
+// executeWait handles the wait step. Every wait is bounded by the step
+// timeout, including a fixed sleep.
+func (e *Engine) executeWait(step *types.Step) error {
+	switch {
+	case step.Selector != "":
+		state := step.State
+		if state == "" {
+			state = browser.StateVisible
+		}
+		return e.page.WaitForSelector(step.Selector, state)
+
+	case step.URL != "":
+		return e.page.WaitForURL(step.URL)
+
+	case step.LoadState != "":
+		return e.page.WaitForLoadState(step.LoadState)
+
+	case step.Expression != "":
+		return e.page.WaitForFunction(step.Expression)
+
+	default:
+		if e.timeout > 0 && step.Sleep > e.timeout {
+			return fmt.Errorf("sleep of %dms exceeds the timeout of %dms", step.Sleep, e.timeout)
+		}
+		time.Sleep(time.Duration(step.Sleep) * time.Millisecond)
+		return nil
+	}
+}
+
+// executeScreenshot saves a screenshot into the output directory, named after
+// the scenario file and step unless the step gives a path
+func (e *Engine) executeScreenshot(step *types.Step) error {
+	name := step.Path
+	if name == "" {
+		name = fmt.Sprintf("%s-step-%d.png", e.artifactPrefix, e.stepIndex)
+	}
+	path := e.artifactPath(name)
+
+	var err error
+	if step.Selector != "" {
+		err = e.page.ElementScreenshot(step.Selector, path)
+	} else {
+		err = e.page.Screenshot(path, step.FullPage)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.artifacts = append(e.artifacts, path)
+	fmt.Fprintf(e.out, "Screenshot saved: %s\n", path)
+	return nil
+}
+
+// captureFailure saves a full-page screenshot and the HTML of the page after
+// a step fails, so failures in CI can be inspected afterwards. Nothing is
+// saved without an output directory. Capture problems are only warned about
+// since the step failure is what gets reported.
+func (e *Engine) captureFailure() {
+	if e.config.OutputDir == "" {
+		return
+	}
+	e.page.SetTimeout(captureTimeout)
+	base := e.artifactPath(fmt.Sprintf("%s-step-%d-failure", e.artifactPrefix, e.stepIndex))
+
+	if err := e.page.Screenshot(base+".png", true); err != nil {
+		fmt.Fprintf(e.out, "Warning: %v\n", err)
+	} else {
+		e.artifacts = append(e.artifacts, base+".png")
+		fmt.Fprintf(e.out, "Failure screenshot saved: %s\n", base+".png")
+	}
+
+	html, err := e.page.Content()
+	if err == nil {
+		err = writeArtifact(base+".html", []byte(html))
+	}
+	if err != nil {
+		fmt.Fprintf(e.out, "Warning: %v\n", err)
+		return
+	}
+	e.artifacts = append(e.artifacts, base+".html")
+	fmt.Fprintf(e.out, "Failure HTML saved: %s\n", base+".html")
+}
+
+// finishScenario keeps the trace and video when their modes ask for it,
+// closes the page and lists the files saved for the scenario in result
+func (e *Engine) finishScenario(result *types.ScenarioResult, videoMode string) {
+	failed := result.Err != nil
+	e.saveTrace(failed)
+
+	video := ""
+	if keepRecording(videoMode, failed) {
+		video = e.artifactPath(e.artifactPrefix + ".webm")
+		e.page.SaveVideo(video)
+	}
+	e.closePage()
+
+	// The video is only written once the page is closed
+	if video != "" {
+		if _, err := os.Stat(video); err == nil {
+			e.artifacts = append(e.artifacts, video)
+			fmt.Fprintf(e.out, "Video saved: %s\n", video)
+		}
+	}
+	result.Artifacts = e.artifacts
+}
+
+// saveTrace stops tracing and keeps the trace of the scenario in the output
+// directory when the trace mode asks for it
+func (e *Engine) saveTrace(failed bool) {
+	if !recording(e.config.Trace) {
+		return
+	}
+
+	path := ""
+	if keepRecording(e.config.Trace, failed) {
+		path = e.artifactPath(e.artifactPrefix + "-trace.zip")
+	}
+	if err := e.page.SaveTrace(path); err != nil {
+		fmt.Fprintf(e.out, "Warning: %v\n", err)
+		return
+	}
+	if path != "" {
+		e.artifacts = append(e.artifacts, path)
+		fmt.Fprintf(e.out, "Trace saved: %s (view with: npx playwright show-trace %s)\n", path, path)
+	}
+}
+
+// videoMode returns when to keep the video of a scenario. The scenario's own
+// setting takes precedence over the configured default.
+func (e *Engine) videoMode(scenario *types.Scenario) string {
+	if scenario.Video != "" {
+		return scenario.Video
+	}
+	return e.config.Video
+}
+
+// recording reports whether a trace or video mode records anything
+func recording(mode string) bool {
+	return mode != "" && mode != types.RecordOff
+}
+
+// keepRecording reports whether a recording made in mode is kept
+func keepRecording(mode string, failed bool) bool {
+	return mode == types.RecordOn || (mode == types.RecordRetainOnFailure && failed)
+}
+
+// artifactPath resolves the name of a saved file against the output directory
+func (e *Engine) artifactPath(name string) string {
+	if filepath.IsAbs(name) || e.config.OutputDir == "" {
+		return name
+	}
+	return filepath.Join(e.config.OutputDir, name)
+}
+
+// artifactPrefix names the files saved for a scenario after its file, e.g.
+// "login" for scenarios/login.yml. With a root directory, the name is the
+// path relative to it with separators replaced, e.g. "admin-login" for
+// scenarios/admin/login.yml under scenarios.
+func artifactPrefix(scenario *types.Scenario, root string) string {
+	if scenario.File == "" {
+		return "scenario"
+	}
+	name := filepath.Base(scenario.File)
+	if root != "" {
+		if rel, err := relativePath(root, scenario.File); err == nil {
+			name = rel
+		}
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.ReplaceAll(filepath.ToSlash(name), "/", "-")
+}
+
+// relativePath returns path relative to root, failing when path is not under root
+func relativePath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not under %s", path, root)
+	}
+	return rel, nil
+}
+
+// writeArtifact writes data to path, creating missing parent directories
+func writeArtifact(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolveFiles returns the paths of an upload step, resolving relative paths
+// against the directory of the file the step is written in
+func resolveFiles(step *types.Step) []string {
+	paths := make([]string, len(step.Files))
+	for i, path := range step.Files {
+		if !filepath.IsAbs(path) && step.Pos.File != "" {
+			path = filepath.Join(filepath.Dir(step.Pos.File), path)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+// executeAssert handles assertion steps. Assertions retry until they pass or
+// the step timeout elapses.
 func (e *Engine) executeAssert(step *types.Step) error {
+	assertion := e.assertion
+	if e.timeout > 0 {
+		assertion = assertion.WithTimeout(e.timeout)
+	}
+	if step.Not {
+		assertion = assertion.Not()
+	}
+
 	switch step.AssertType {
 	case "text_content":
-		if step.Selector == "" {
-			return fmt.Errorf("text_content assertion requires selector")
-		}
-		if step.Contains == "" {
-			return fmt.Errorf("text_content assertion requires contains value")
-		}
-		return e.assertion.AssertTextContent(step.Selector, step.Contains)
+		return assertion.AssertText(step.Selector, textMatch(step))
 
 	case "url":
-		if step.Contains == "" {
-			return fmt.Errorf("url assertion requires contains value")
-		}
-		return e.assertion.AssertURLContains(step.Contains)
+		return assertion.AssertURLMatches(textMatch(step))
+
+	case "title":
+		return assertion.AssertTitle(textMatch(step))
 
 	case "exists":
-		if step.Selector == "" {
-			return fmt.Errorf("exists assertion requires selector")
+		return assertion.AssertExists(step.Selector)
+
+	case "not_exists":
+		return assertion.Not().AssertExists(step.Selector)
+
+	case "visible":
+		return assertion.AssertVisible(step.Selector)
+
+	case "hidden":
+		return assertion.Not().AssertVisible(step.Selector)
+
+	case "enabled":
+		return assertion.AssertEnabled(step.Selector)
+
+	case "disabled":
+		return assertion.AssertDisabled(step.Selector)
+
+	case "checked":
+		return assertion.AssertChecked(step.Selector)
+
+	case "unchecked":
+		return assertion.AssertUnchecked(step.Selector)
+
+	case "selected":
+		return assertion.AssertSelected(step.Selector, step.OptionValues, step.OptionLabels)
+
+	case "count":
+		var equals *int
+		if step.Equals != "" {
+			count, err := strconv.Atoi(step.Equals)
+			if err != nil {
+				return fmt.Errorf("count assertion equals must be a number, got %q", step.Equals)
+			}
+			equals = &count
 		}
-		return e.assertion.AssertExists(step.Selector)
+		return assertion.AssertCount(step.Selector, equals, step.Min, step.Max)
+
+	case "attribute":
+		return assertion.AssertAttribute(step.Selector, step.Attribute, step.Value)
+
+	case "value":
+		return assertion.AssertValue(step.Selector, step.Value)
+
+	case "css":
+		return assertion.AssertCSS(step.Selector, step.Property, step.Value)
 
 	default:
 		return fmt.Errorf("unknown assertion type: %s", step.AssertType)
 	}
 }
 
-// Close cleans up the engine resources
+// executeStore saves a value read from the page into a variable
+func (e *Engine) executeStore(step *types.Step) error {
+	value, err := e.readValue(step)
+	if err != nil {
+		return err
+	}
+
+	e.vars[step.Variable] = value
+	fmt.Fprintf(e.out, "Stored variable %s\n", step.Variable)
+	return nil
+}
+
+// readValue reads the value described by a store step from the page
+func (e *Engine) readValue(step *types.Step) (string, error) {
+	switch step.Source {
+	case "text", "":
+		return e.page.GetElementText(step.Selector)
+
+	case "value":
+		return e.page.GetElementValue(step.Selector)
+
+	case "attribute":
+		return e.page.GetElementAttribute(step.Selector, step.Attribute)
+
+	case "url":
+		return e.page.URL(), nil
+
+	case "eval":
+		result, err := e.page.Evaluate(step.Expression)
+		if err != nil {
+			return "", err
+		}
+		return stringifyResult(result)
+
+	default:
+		return "", fmt.Errorf("unknown store source: %s", step.Source)
+	}
+}
+
+// stringifyResult converts a JavaScript evaluation result into a variable value
+func stringifyResult(result interface{}) (string, error) {
+	switch v := result.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int, bool:
+		return fmt.Sprint(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert evaluation result: %w", err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// Close cleans up the engine resources. A shared browser is left open.
 func (e *Engine) Close() error {
-	if e.browser != nil {
+	if e.ownsBrowser && e.browser != nil {
 		return e.browser.Close()
 	}
 	return nil
@@ -1,8 +1,19 @@
 package executor
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/haruotsu/ezpw/internal/playwright"
 	"github.com/haruotsu/ezpw/pkg/types"
 )
 
@@ -161,3 +172,724 @@ func TestEngineStepTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestEngineTimeouts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	// Step timeout overrides the global timeout
+	start := time.Now()
+	err = engine.Execute(&types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body></body></html>"},
+			{Type: "click", Selector: "#missing", Timeout: 500},
+		},
+	})
+	if err == nil {
+		t.Error("Expected error clicking missing element, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected step timeout to apply, took %s", elapsed)
+	}
+
+	// Scenario deadline caps every step
+	err = engine.Execute(&types.Scenario{
+		Timeout: 500,
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body></body></html>"},
+			{Type: "click", Selector: "#missing"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "scenario timeout") {
+		t.Errorf("Expected scenario timeout error, got %v", err)
+	}
+}
+
+func TestEngineVariables(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	t.Setenv("EZPW_TEST_GREETING", "Hello")
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+		Vars:     map[string]string{"NAME": "from-cli"},
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	scenario := &types.Scenario{
+		Vars: map[string]string{
+			"NAME":    "from-scenario",
+			"MESSAGE": "${EZPW_TEST_GREETING}, ${NAME}",
+		},
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body><input id='test'></body></html>"},
+			{Type: "fill", Selector: "#test", Value: "${MESSAGE}"},
+		},
+	}
+
+	err = engine.Execute(scenario)
+	if err != nil {
+		t.Fatalf("Expected no error executing scenario, got %v", err)
+	}
+
+	err = engine.Execute(&types.Scenario{
+		Steps: []types.Step{{Type: "goto", URL: "${UNDEFINED_VARIABLE}"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "undefined variable: UNDEFINED_VARIABLE") {
+		t.Errorf("Expected undefined variable error, got %v", err)
+	}
+}
+
+func TestEngineStoreStep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	html := "data:text/html,<html><body>" +
+		"<span id='order'>A-123</span>" +
+		"<a id='next' href='/orders/A-123'>next</a>" +
+		"<input id='copy'><div id='result'></div>" +
+		"</body></html>"
+
+	scenario := &types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: html},
+			{Type: "store", Variable: "ORDER", Source: "text", Selector: "#order"},
+			{Type: "store", Variable: "LINK", Source: "attribute", Selector: "#next", Attribute: "href"},
+			{Type: "store", Variable: "TWO", Source: "eval", Expression: "1 + 1"},
+			{Type: "fill", Selector: "#copy", Value: "${ORDER} ${LINK} ${TWO}"},
+			{Type: "store", Variable: "COPIED", Source: "value", Selector: "#copy"},
+		},
+	}
+
+	err = engine.Execute(scenario)
+	if err != nil {
+		t.Fatalf("Expected no error executing scenario, got %v", err)
+	}
+
+	if engine.vars["COPIED"] != "A-123 /orders/A-123 2" {
+		t.Errorf("Expected stored values to be interpolated, got '%s'", engine.vars["COPIED"])
+	}
+}
+
+func TestStringifyResult(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{input: nil, expected: ""},
+		{input: "text", expected: "text"},
+		{input: float64(42), expected: "42"},
+		{input: 1.5, expected: "1.5"},
+		{input: true, expected: "true"},
+		{input: []interface{}{"a", float64(1)}, expected: `["a",1]`},
+	}
+
+	for _, tt := range tests {
+		actual, err := stringifyResult(tt.input)
+		if err != nil {
+			t.Errorf("stringifyResult(%v): expected no error, got %v", tt.input, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("stringifyResult(%v): expected %q, got %q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestEngineErrorLocation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  1000,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	err = engine.Execute(&types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body></body></html>"},
+			{
+				Type:     "click",
+				Selector: "#submit",
+				Pos:      types.Position{File: "login.yml", Line: 14, Column: 5},
+			},
+		},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), `login.yml:14:5: click "#submit": `) {
+		t.Errorf("Expected error located at login.yml:14:5, got %v", err)
+	}
+}
+
+func TestEngineKeyboardSteps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	page := "data:text/html,<html><body><form onsubmit=\"document.title='submitted';return false\">" +
+		"<input id='q'></form></body></html>"
+	scenario := &types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: page},
+			{Type: "type", Selector: "#q", Value: "ezpw", Delay: 5},
+			{Type: "press", Selector: "#q", Key: "Enter"},
+			{Type: "store", Variable: "title", Source: "eval", Expression: "document.title"},
+			{Type: "store", Variable: "query", Source: "value", Selector: "#q"},
+		},
+	}
+
+	if err := engine.Execute(scenario); err != nil {
+		t.Fatalf("Expected no error executing scenario, got %v", err)
+	}
+	if engine.vars["title"] != "submitted" || engine.vars["query"] != "ezpw" {
+		t.Errorf("Expected submitted form with query 'ezpw', got title %q and query %q", engine.vars["title"], engine.vars["query"])
+	}
+}
+
+func TestEngineScreenshots(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:   "chromium",
+		Headless:  true,
+		Timeout:   1000,
+		OutputDir: t.TempDir(),
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	result := engine.Run(&types.Scenario{
+		File: filepath.Join("scenarios", "login.yml"),
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body><h1>Login</h1></body></html>"},
+			{Type: "screenshot"},
+			{Type: "screenshot", Path: "heading.png", Selector: "h1"},
+			{Type: "click", Selector: "#missing"},
+		},
+	})
+	if result.Err == nil {
+		t.Fatal("Expected click on missing element to fail, got nil")
+	}
+
+	expected := []string{"login-step-2.png", "heading.png", "login-step-4-failure.png", "login-step-4-failure.html"}
+	if len(result.Artifacts) != len(expected) {
+		t.Fatalf("Expected artifacts %v, got %v", expected, result.Artifacts)
+	}
+	for i, name := range expected {
+		path := filepath.Join(config.OutputDir, name)
+		if result.Artifacts[i] != path {
+			t.Errorf("Expected artifact %d to be %s, got %s", i, path, result.Artifacts[i])
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be written: %v", path, err)
+		}
+	}
+
+	html, err := os.ReadFile(filepath.Join(config.OutputDir, "login-step-4-failure.html"))
+	if err != nil || !strings.Contains(string(html), "<h1>Login</h1>") {
+		t.Errorf("Expected page HTML to be dumped, got %q (%v)", html, err)
+	}
+}
+
+func TestEngineTrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:   "chromium",
+		Headless:  true,
+		Timeout:   1000,
+		OutputDir: t.TempDir(),
+		Trace:     types.RecordRetainOnFailure,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	page := "data:text/html,<html><body><button id='ok'>OK</button></body></html>"
+	passed := engine.Run(&types.Scenario{
+		File:  "passed.yml",
+		Steps: []types.Step{{Type: "goto", URL: page}, {Type: "click", Selector: "#ok"}},
+	})
+	if passed.Err != nil {
+		t.Fatalf("Expected no error, got %v", passed.Err)
+	}
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "passed-trace.zip")); !os.IsNotExist(err) {
+		t.Errorf("Expected no trace for a passing scenario in retain-on-failure mode, got %v", err)
+	}
+
+	failed := engine.Run(&types.Scenario{
+		File:  "failed.yml",
+		Steps: []types.Step{{Type: "goto", URL: page}, {Type: "click", Selector: "#missing"}},
+	})
+	if failed.Err == nil {
+		t.Fatal("Expected click on missing element to fail, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "failed-trace.zip")); err != nil {
+		t.Errorf("Expected trace of the failed scenario to be saved: %v", err)
+	}
+}
+
+func TestEngineVideo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:   "chromium",
+		Headless:  true,
+		Timeout:   1000,
+		OutputDir: t.TempDir(),
+		Video:     types.RecordRetainOnFailure,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	page := "data:text/html,<html><body><button id='ok'>OK</button></body></html>"
+
+	// A passing scenario keeps its video when the scenario asks for it
+	result := engine.Run(&types.Scenario{
+		File:  "checkout.yml",
+		Video: types.RecordOn,
+		Steps: []types.Step{{Type: "goto", URL: page}, {Type: "click", Selector: "#ok"}},
+	})
+	if result.Err != nil {
+		t.Fatalf("Expected no error, got %v", result.Err)
+	}
+	video := filepath.Join(config.OutputDir, "checkout.webm")
+	if _, err := os.Stat(video); err != nil {
+		t.Errorf("Expected video to be saved: %v", err)
+	}
+	if len(result.Artifacts) != 1 || result.Artifacts[0] != video {
+		t.Errorf("Expected video in artifacts, got %v", result.Artifacts)
+	}
+
+	// retain-on-failure drops the video of a passing scenario
+	result = engine.Run(&types.Scenario{
+		File:  "login.yml",
+		Steps: []types.Step{{Type: "goto", URL: page}},
+	})
+	if result.Err != nil {
+		t.Fatalf("Expected no error, got %v", result.Err)
+	}
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "login.webm")); !os.IsNotExist(err) {
+		t.Errorf("Expected no video for a passing scenario, got %v", err)
+	}
+}
+
+func TestEngineSoftAssertions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  500,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	result := engine.Run(&types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body><h1>Cart</h1><p id=total>$10</p></body></html>"},
+			{Type: "assert", AssertType: "text_content", Selector: "h1", Contains: "Checkout", Soft: true},
+			{Type: "assert", AssertType: "text_content", Selector: "#total", Contains: "$20", Soft: true},
+			{Type: "assert", AssertType: "exists", Selector: "#total"},
+		},
+	})
+	if result.Status != types.StatusFailed {
+		t.Fatalf("Expected scenario to fail, got %s", result.Status)
+	}
+	if result.Steps[3].Status != types.StatusPassed {
+		t.Errorf("Expected step after soft failures to run, got %s", result.Steps[3].Status)
+	}
+	message := result.Err.Error()
+	if !strings.Contains(message, "step 2 failed") || !strings.Contains(message, "step 3 failed") {
+		t.Errorf("Expected both soft failures to be reported, got %v", message)
+	}
+
+	// With soft_assertions, a failing non-assertion step still stops the scenario
+	result = engine.Run(&types.Scenario{
+		SoftAssertions: true,
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body><h1>Cart</h1></body></html>"},
+			{Type: "assert", AssertType: "exists", Selector: "#banner"},
+			{Type: "click", Selector: "#checkout"},
+			{Type: "assert", AssertType: "exists", Selector: "h1"},
+		},
+	})
+	if result.Steps[3].Status != types.StatusSkipped {
+		t.Errorf("Expected step after a failed click to be skipped, got %s", result.Steps[3].Status)
+	}
+	message = result.Err.Error()
+	if !strings.Contains(message, "step 2 failed") || !strings.Contains(message, "step 3 failed") {
+		t.Errorf("Expected the soft failure and the click failure, got %v", message)
+	}
+
+	// Soft assertions nested in blocks are recorded and let the block continue
+	interval := 0
+	result = engine.Run(&types.Scenario{
+		SoftAssertions: true,
+		Steps: []types.Step{
+			{Type: "goto", URL: "data:text/html,<html><body><h1>Cart</h1></body></html>"},
+			{Type: "if", If: &types.Condition{Exists: "h1"}, Steps: []types.Step{
+				{Type: "assert", AssertType: "exists", Selector: "#banner"},
+				{Type: "assert", AssertType: "exists", Selector: "h1"},
+			}},
+			{Type: "retry_until", MaxAttempts: 2, Interval: &interval, Steps: []types.Step{
+				{Type: "assert", AssertType: "exists", Selector: "#coupon"},
+			}},
+			{Type: "assert", AssertType: "exists", Selector: "h1"},
+		},
+	})
+	if result.Steps[1].Status != types.StatusFailed || result.Steps[2].Status != types.StatusFailed {
+		t.Errorf("Expected blocks with soft failures to be failed, got %s and %s", result.Steps[1].Status, result.Steps[2].Status)
+	}
+	if result.Steps[3].Status != types.StatusPassed {
+		t.Errorf("Expected step after the blocks to run, got %s", result.Steps[3].Status)
+	}
+	if count := strings.Count(result.Err.Error(), "step 1 failed"); count != 2 {
+		t.Errorf("Expected one soft failure from each block, got %d: %v", count, result.Err)
+	}
+}
+
+func TestEngineRetryUntil(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  300,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	// The status shows done after the third refresh
+	page := "data:text/html,<html><body><button id='refresh' onclick=\"" +
+		"this.dataset.n=(+this.dataset.n||0)+1;if(this.dataset.n>=3)status.textContent='done'\">Refresh</button>" +
+		"<p id='status'>pending</p></body></html>"
+	interval := 0
+	block := types.Step{
+		Type:     "retry_until",
+		Interval: &interval,
+		Steps: []types.Step{
+			{Type: "click", Selector: "#refresh"},
+			{Type: "assert", AssertType: "text_content", Selector: "#status", Contains: "done"},
+		},
+	}
+
+	block.MaxAttempts = 3
+	result := engine.Run(&types.Scenario{Steps: []types.Step{{Type: "goto", URL: page}, block}})
+	if result.Err != nil {
+		t.Errorf("Expected block to pass on the third attempt, got %v", result.Err)
+	}
+
+	block.MaxAttempts = 2
+	result = engine.Run(&types.Scenario{Steps: []types.Step{{Type: "goto", URL: page}, block}})
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "steps did not pass after 2 attempt(s): step 2 failed: ") {
+		t.Errorf("Expected block to fail after 2 attempts, got %v", result.Err)
+	}
+}
+
+// refreshPage is a page on which #done appears after #refresh is clicked
+// the given number of times
+type refreshPage struct {
+	browser.Page
+	clicks, needed int
+}
+
+func (p *refreshPage) SetTimeout(int) {}
+
+func (p *refreshPage) Click(string) error {
+	p.clicks++
+	return nil
+}
+
+func (p *refreshPage) GetElementCount(string) (int, error) {
+	if p.clicks >= p.needed {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestRetryUntilSoftAssertions(t *testing.T) {
+	interval := 0
+	block := &types.Step{
+		Type:        "retry_until",
+		MaxAttempts: 3,
+		Interval:    &interval,
+		Steps: []types.Step{
+			{Type: "click", Selector: "#refresh"},
+			{Type: "assert", AssertType: "exists", Selector: "#done"},
+		},
+	}
+	newEngine := func(page *refreshPage) *Engine {
+		return &Engine{
+			page:           page,
+			assertion:      playwright.NewAssertion(page),
+			out:            io.Discard,
+			config:         types.Config{Timeout: 1},
+			softAssertions: true,
+		}
+	}
+
+	// Under soft_assertions a failed assertion still fails the attempt
+	page := &refreshPage{needed: 2}
+	engine := newEngine(page)
+	if err := engine.executeRetryUntil(block); err != nil {
+		t.Fatalf("Expected block to pass on the second attempt, got %v", err)
+	}
+	if page.clicks != 2 || len(engine.softFailures) != 0 {
+		t.Errorf("Expected 2 attempts and no soft failures, got %d and %v", page.clicks, engine.softFailures)
+	}
+
+	// Failing every attempt records the last failure as soft
+	page = &refreshPage{needed: 10}
+	engine = newEngine(page)
+	if err := engine.executeRetryUntil(block); err != nil {
+		t.Fatalf("Expected the last failure to be soft, got %v", err)
+	}
+	if page.clicks != 3 || len(engine.softFailures) != 1 ||
+		!strings.Contains(engine.softFailures[0].Error(), "steps did not pass after 3 attempt(s): step 2 failed: ") {
+		t.Errorf("Expected 3 attempts and one soft failure, got %d and %v", page.clicks, engine.softFailures)
+	}
+
+	// Without soft assertions the last failure stops the scenario
+	engine = newEngine(&refreshPage{needed: 10})
+	engine.softAssertions = false
+	if err := engine.executeRetryUntil(block); err == nil || len(engine.softFailures) != 0 {
+		t.Errorf("Expected a hard failure, got %v and %v", err, engine.softFailures)
+	}
+}
+
+func TestEngineConditions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  300,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	page := "data:text/html,<html><body><p id='status'>pending</p>" +
+		"<div id='banner'><button id='accept' onclick='banner.remove()'>OK</button></div></body></html>"
+	production := "production"
+	scenario := &types.Scenario{
+		Vars: map[string]string{"ENV": "staging"},
+		Steps: []types.Step{
+			{Type: "goto", URL: page},
+			{Type: "click", Selector: "#accept", If: &types.Condition{Exists: "#banner"}},
+			{Type: "click", Selector: "#accept", If: &types.Condition{Exists: "#banner"}},
+			{
+				Type:  "if",
+				If:    &types.Condition{Variable: "ENV", Equals: &production},
+				Steps: []types.Step{{Type: "click", Selector: "#missing"}},
+				Else:  []types.Step{{Type: "assert", AssertType: "text_content", Selector: "#status", Contains: "pending"}},
+			},
+			{Type: "assert", AssertType: "not_exists", Selector: "#banner", Unless: &types.Condition{Visible: "#banner"}},
+		},
+	}
+
+	result := engine.Run(scenario)
+	if result.Err != nil {
+		t.Fatalf("Expected no error, got %v", result.Err)
+	}
+	expected := []string{types.StatusPassed, types.StatusPassed, types.StatusSkipped, types.StatusPassed, types.StatusPassed}
+	for i, step := range result.Steps {
+		if step.Status != expected[i] {
+			t.Errorf("Expected step %d to be %s, got %s", i+1, expected[i], step.Status)
+		}
+	}
+}
+
+func TestBlockDeadline(t *testing.T) {
+	engine := &Engine{deadline: time.Now().Add(time.Hour), limit: "scenario deadline"}
+	scenarioDeadline := engine.deadline
+
+	block := &types.Step{Type: "retry_until", Timeout: 1}
+	err := engine.withinBlock(block, func() error {
+		time.Sleep(5 * time.Millisecond)
+		return engine.applyStepTimeout(&types.Step{Type: "click"})
+	})
+	if err == nil || err.Error() != "no time left before the 1ms timeout of the retry_until step" {
+		t.Errorf("Expected the block timeout to be named, got %v", err)
+	}
+	if !engine.deadline.Equal(scenarioDeadline) || engine.limit != "scenario deadline" {
+		t.Errorf("Expected the scenario deadline to be restored, got %v (%s)", engine.deadline, engine.limit)
+	}
+
+	// A longer block timeout does not extend an earlier deadline
+	engine.deadline = time.Now().Add(-time.Millisecond)
+	err = engine.withinBlock(&types.Step{Type: "if", Timeout: 60000}, func() error {
+		return engine.applyStepTimeout(&types.Step{Type: "click"})
+	})
+	if err == nil || err.Error() != "no time left before scenario deadline" {
+		t.Errorf("Expected the scenario deadline to be named, got %v", err)
+	}
+}
+
+func TestEngineRetries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	// The page shows the expected status from the second request on
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		status := "Ready"
+		if requests.Add(1) == 1 {
+			status = "Down"
+		}
+		fmt.Fprintf(w, "<html><body><p id='status'>%s</p></body></html>", status)
+	}))
+	defer server.Close()
+
+	config := types.Config{
+		Browser:   "chromium",
+		Headless:  true,
+		Timeout:   500,
+		OutputDir: t.TempDir(),
+		Retries:   2,
+	}
+
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating engine, got %v", err)
+	}
+	defer engine.Close()
+
+	scenario := &types.Scenario{
+		File: "status.yml",
+		Steps: []types.Step{
+			{Type: "goto", URL: server.URL},
+			{Type: "assert", AssertType: "text_content", Selector: "#status", Contains: "Ready"},
+		},
+	}
+	result := engine.Run(scenario)
+	if result.Status != types.StatusFlaky || result.Attempts != 2 || len(result.RetriedErrs) != 1 {
+		t.Errorf("Expected flaky scenario passing on attempt 2, got %+v", result)
+	}
+	failure := filepath.Join(config.OutputDir, "status-step-2-failure.png")
+	if _, err := os.Stat(failure); err != nil {
+		t.Errorf("Expected failure screenshot of the first attempt to be kept: %v", err)
+	}
+
+	// A scenario's own retries override the configured ones
+	none := 0
+	scenario.Retries = &none
+	scenario.Steps[1].Contains = "Maintenance"
+	result = engine.Run(scenario)
+	if result.Status != types.StatusFailed || result.Attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v", result)
+	}
+}
+
+func TestArtifactPrefix(t *testing.T) {
+	tests := []struct {
+		file     string
+		root     string
+		expected string
+	}{
+		{file: "", expected: "scenario"},
+		{file: "login.yml", expected: "login"},
+		{file: filepath.Join("tests", "checkout.flow.yaml"), expected: "checkout.flow"},
+		{file: filepath.Join("tests", "login.yml"), root: "tests", expected: "login"},
+		{file: filepath.Join("tests", "a", "login.yml"), root: "tests", expected: "a-login"},
+		{file: filepath.Join("tests", "b", "login.yml"), root: "tests", expected: "b-login"},
+		{file: filepath.Join("other", "login.yml"), root: "tests", expected: "login"},
+	}
+
+	for _, tt := range tests {
+		actual := artifactPrefix(&types.Scenario{File: tt.file}, tt.root)
+		if actual != tt.expected {
+			t.Errorf("artifactPrefix(%q, %q): expected %q, got %q", tt.file, tt.root, tt.expected, actual)
+		}
+	}
+}
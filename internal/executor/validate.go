@@ -0,0 +1,411 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/haruotsu/ezpw/internal/parser"
+	"github.com/haruotsu/ezpw/internal/playwright"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+// AssertionType describes an assertion type and the step keys it requires
+type AssertionType struct {
+	Name        string
+	Description string
+	Required    []string
+	// RequireOne lists keys of which exactly one must be given
+	RequireOne []string
+	// RequireAny lists keys of which at least one must be given
+	RequireAny []string
+}
+
+// assertionTypes lists every assertion type supported by the engine. Every
+// type can be negated with not: true.
+var assertionTypes = []AssertionType{
+	{
+		Name:        "text_content",
+		Description: "Element text matches the expected text",
+		Required:    []string{"selector"},
+		RequireOne:  textMatchKeys,
+	},
+	{Name: "url", Description: "Page URL matches the expected text", RequireOne: textMatchKeys},
+	{Name: "exists", Description: "Element exists on the page", Required: []string{"selector"}},
+	{Name: "not_exists", Description: "Element does not exist on the page", Required: []string{"selector"}},
+	{Name: "visible", Description: "Element is visible", Required: []string{"selector"}},
+	{Name: "hidden", Description: "Element is hidden or does not exist", Required: []string{"selector"}},
+	{Name: "enabled", Description: "Element is enabled", Required: []string{"selector"}},
+	{Name: "disabled", Description: "Element is disabled", Required: []string{"selector"}},
+	{Name: "checked", Description: "Checkbox or radio button is checked", Required: []string{"selector"}},
+	{Name: "unchecked", Description: "Checkbox or radio button is not checked", Required: []string{"selector"}},
+	{
+		Name:        "selected",
+		Description: "Selected options of a <select> element are exactly the given values or labels",
+		Required:    []string{"selector"},
+		RequireOne:  []string{"value", "label"},
+	},
+	{
+		Name:        "count",
+		Description: "Number of elements matching the selector is equals, or between min and max",
+		Required:    []string{"selector"},
+		RequireAny:  []string{"equals", "min", "max"},
+	},
+	{Name: "attribute", Description: "Attribute name of the element has the expected value", Required: []string{"selector", "name", "value"}},
+	{Name: "value", Description: "Input, textarea or select element has the expected value", Required: []string{"selector", "value"}},
+	{
+		Name:        "title",
+		Description: "Page title matches the expected text; value is the same as equals",
+		RequireOne:  append([]string{"value"}, textMatchKeys...),
+	},
+	{Name: "css", Description: "Computed CSS property of the element has the expected value", Required: []string{"selector", "property", "value"}},
+}
+
+// textMatchKeys are the keys of the text match modes of text_content, url and
+// title assertions
+var textMatchKeys = []string{"contains", "equals", "matches", "starts_with", "ends_with"}
+
+// AssertionTypes returns the assertion types supported by the engine
+func AssertionTypes() []AssertionType {
+	return append([]AssertionType(nil), assertionTypes...)
+}
+
+// Validate checks that every step of a scenario has what the engine needs to
+// execute it, without opening a browser. Each error is located at its step.
+func Validate(scenario *types.Scenario) []error {
+	return validateSteps(scenario.Steps)
+}
+
+// validateSteps checks steps and the steps nested in them
+func validateSteps(steps []types.Step) []error {
+	var errs []error
+	for i := range steps {
+		step := &steps[i]
+		if err := checkStep(step); err != nil {
+			errs = append(errs, stepFailure(i, step, err))
+		}
+		errs = append(errs, validateSteps(step.Steps)...)
+		errs = append(errs, validateSteps(step.Else)...)
+	}
+	return errs
+}
+
+// checkStep reports a missing or invalid field of a step
+func checkStep(step *types.Step) error {
+	for _, condition := range []*types.Condition{step.If, step.Unless} {
+		if err := checkCondition(condition); err != nil {
+			return err
+		}
+	}
+	if step.If != nil && step.Unless != nil {
+		return fmt.Errorf("step cannot combine if with unless")
+	}
+
+	switch step.Type {
+	case "goto":
+		if step.URL == "" {
+			return fmt.Errorf("goto step requires URL")
+		}
+
+	case "click", "dblclick", "right_click":
+		if step.Selector == "" && step.Point == nil {
+			return fmt.Errorf("%s step requires selector or position", step.Type)
+		}
+
+	case "hover":
+		if step.Selector == "" {
+			return fmt.Errorf("hover step requires selector")
+		}
+
+	case "drag":
+		if step.Selector == "" {
+			return fmt.Errorf("drag step requires from")
+		}
+		if step.Target == "" {
+			return fmt.Errorf("drag step requires to")
+		}
+
+	case "fill":
+		if step.Selector == "" {
+			return fmt.Errorf("fill step requires selector")
+		}
+		if step.Value == "" {
+			return fmt.Errorf("fill step requires value")
+		}
+
+	case "select":
+		if step.Selector == "" {
+			return fmt.Errorf("select step requires selector")
+		}
+		if step.OptionValues == nil && step.OptionLabels == nil && step.OptionIndexes == nil {
+			return fmt.Errorf("select step requires value, label or index")
+		}
+
+	case "check", "uncheck":
+		if step.Selector == "" {
+			return fmt.Errorf("%s step requires selector", step.Type)
+		}
+
+	case "upload":
+		if step.Selector == "" {
+			return fmt.Errorf("upload step requires selector")
+		}
+		if step.Files == nil {
+			return fmt.Errorf("upload step requires files")
+		}
+
+	case "press", "key_down", "key_up":
+		if step.Key == "" {
+			return fmt.Errorf("%s step requires key", step.Type)
+		}
+
+	case "type":
+		if step.Value == "" {
+			return fmt.Errorf("type step requires text")
+		}
+
+	case "wait":
+		return checkWait(step)
+
+	case "screenshot":
+		if step.FullPage && step.Selector != "" {
+			return fmt.Errorf("screenshot step cannot combine full_page with selector")
+		}
+
+	case "retry_until":
+		if len(step.Steps) == 0 {
+			return fmt.Errorf("retry_until step requires steps")
+		}
+		if step.MaxAttempts < 0 {
+			return fmt.Errorf("retry_until max_attempts must be at least 1, got %d", step.MaxAttempts)
+		}
+
+	case "if":
+		if step.If == nil && step.Unless == nil {
+			return fmt.Errorf("if block requires if or unless")
+		}
+		if len(step.Steps) == 0 {
+			return fmt.Errorf("if block requires then steps")
+		}
+
+	case "assert":
+		return checkAssert(step)
+
+	case "store":
+		return checkStore(step)
+
+	default:
+		return fmt.Errorf("unknown step type: %s", step.Type)
+	}
+	return nil
+}
+
+// checkAssert reports an unknown assertion type or a missing required key
+func checkAssert(step *types.Step) error {
+	spec := lookupAssertion(step.AssertType)
+	if spec == nil {
+		return fmt.Errorf("unknown assertion type: %s%s (expected one of: %s)", step.AssertType,
+			parser.Suggestion(step.AssertType, assertionNames()), strings.Join(assertionNames(), ", "))
+	}
+
+	for _, key := range spec.Required {
+		if !hasAssertionField(step, key) {
+			return fmt.Errorf("%s assertion requires %s", spec.Name, key)
+		}
+	}
+
+	if len(spec.RequireOne) > 0 && countAssertionFields(step, spec.RequireOne) != 1 {
+		return fmt.Errorf("%s assertion requires exactly one of: %s", spec.Name, strings.Join(spec.RequireOne, ", "))
+	}
+	if len(spec.RequireAny) > 0 && countAssertionFields(step, spec.RequireAny) == 0 {
+		return fmt.Errorf("%s assertion requires %s", spec.Name, strings.Join(spec.RequireAny, " or "))
+	}
+
+	if spec.Name != "selected" && len(step.OptionValues) > 1 {
+		return fmt.Errorf("%s assertion requires a single value", spec.Name)
+	}
+	switch spec.Name {
+	case "text_content", "url", "title":
+		if err := textMatch(step).Validate(); err != nil && !strings.Contains(step.Matches, "${") {
+			return fmt.Errorf("%s assertion: %w", spec.Name, err)
+		}
+	}
+	if spec.Name == "count" && step.Equals != "" {
+		if _, err := strconv.Atoi(step.Equals); err != nil && !strings.Contains(step.Equals, "${") {
+			return fmt.Errorf("count assertion equals must be a number, got %q", step.Equals)
+		}
+	}
+	return nil
+}
+
+// textMatch builds the comparison of a text_content, url or title assertion
+// from whichever match key the step gives
+func textMatch(step *types.Step) playwright.TextMatch {
+	match := playwright.TextMatch{
+		Mode:                playwright.MatchContains,
+		Expected:            step.Contains,
+		NormalizeWhitespace: step.NormalizeWhitespace,
+		IgnoreCase:          step.IgnoreCase,
+	}
+	switch {
+	case step.Equals != "":
+		match.Mode, match.Expected = playwright.MatchEquals, step.Equals
+	case step.Matches != "":
+		match.Mode, match.Expected = playwright.MatchRegexp, step.Matches
+	case step.StartsWith != "":
+		match.Mode, match.Expected = playwright.MatchStartsWith, step.StartsWith
+	case step.EndsWith != "":
+		match.Mode, match.Expected = playwright.MatchEndsWith, step.EndsWith
+	case step.Contains == "" && step.Value != "":
+		match.Mode, match.Expected = playwright.MatchEquals, step.Value
+	}
+	return match
+}
+
+// countAssertionFields returns how many of keys are given in an assert step
+func countAssertionFields(step *types.Step, keys []string) int {
+	given := 0
+	for _, key := range keys {
+		if hasAssertionField(step, key) {
+			given++
+		}
+	}
+	return given
+}
+
+// checkStore reports an unknown source or a key missing for the source of a store step
+func checkStore(step *types.Step) error {
+	if step.Variable == "" {
+		return fmt.Errorf("store step requires name")
+	}
+
+	source := step.Source
+	if source == "" {
+		source = "text"
+	}
+	switch source {
+	case "text", "value":
+		if step.Selector == "" {
+			return fmt.Errorf("store from %s requires selector", source)
+		}
+	case "attribute":
+		if step.Selector == "" {
+			return fmt.Errorf("store from attribute requires selector")
+		}
+		if step.Attribute == "" {
+			return fmt.Errorf("store from attribute requires attribute")
+		}
+	case "url":
+	case "eval":
+		if step.Expression == "" {
+			return fmt.Errorf("store from eval requires expression")
+		}
+	default:
+		return fmt.Errorf("unknown store source: %s", step.Source)
+	}
+	return nil
+}
+
+// lookupAssertion returns the spec of an assertion type
+func lookupAssertion(name string) *AssertionType {
+	for i := range assertionTypes {
+		if assertionTypes[i].Name == name {
+			return &assertionTypes[i]
+		}
+	}
+	return nil
+}
+
+// assertionNames returns the names of the supported assertion types
+func assertionNames() []string {
+	names := make([]string, len(assertionTypes))
+	for i, spec := range assertionTypes {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// hasAssertionField reports whether an assertion step key is given
+func hasAssertionField(step *types.Step, key string) bool {
+	switch key {
+	case "selector":
+		return step.Selector != ""
+	case "contains":
+		return step.Contains != ""
+	case "value":
+		return step.OptionValues != nil || step.Value != ""
+	case "label":
+		return step.OptionLabels != nil
+	case "name":
+		return step.Attribute != ""
+	case "property":
+		return step.Property != ""
+	case "equals":
+		return step.Equals != ""
+	case "matches":
+		return step.Matches != ""
+	case "starts_with":
+		return step.StartsWith != ""
+	case "ends_with":
+		return step.EndsWith != ""
+	case "min":
+		return step.Min != nil
+	case "max":
+		return step.Max != nil
+	default:
+		return false
+	}
+}
+
+// checkCondition reports a condition that does not check exactly one thing,
+// or whose comparison does not fit what it checks
+func checkCondition(condition *types.Condition) error {
+	if condition == nil {
+		return nil
+	}
+
+	given := 0
+	for _, set := range []bool{condition.Exists != "", condition.Visible != "", condition.URL != "", condition.URLMatches != "", condition.Variable != ""} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 {
+		return fmt.Errorf("condition requires exactly one of: %s", strings.Join(parser.ConditionChecks(), ", "))
+	}
+	if (condition.Equals != nil || condition.NotEquals != nil) && condition.Variable == "" {
+		return fmt.Errorf("condition equals and not_equals require var")
+	}
+	if condition.Equals != nil && condition.NotEquals != nil {
+		return fmt.Errorf("condition cannot combine equals with not_equals")
+	}
+	if condition.URLMatches != "" && !strings.Contains(condition.URLMatches, "${") {
+		if _, err := regexp.Compile(condition.URLMatches); err != nil {
+			return fmt.Errorf("condition url_matches: invalid regular expression: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkWait checks that a wait step waits for exactly one thing: a selector,
+// URL, load state or function, or a sleep of step.Sleep milliseconds
+func checkWait(step *types.Step) error {
+	if step.Sleep < 0 {
+		return fmt.Errorf("wait step sleep must not be negative")
+	}
+
+	conditions := 0
+	for _, set := range []bool{step.Selector != "", step.URL != "", step.LoadState != "", step.Expression != "", step.Sleep > 0} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions != 1 {
+		return fmt.Errorf("wait step requires exactly one of: selector, url, load_state, function, sleep")
+	}
+	if step.State != "" && step.Selector == "" {
+		return fmt.Errorf("wait step state requires selector")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	production := "production"
+	scenario := &types.Scenario{
+		Steps: []types.Step{
+			{Type: "goto", URL: "https://example.com"},
+			{Type: "assert", AssertType: "text_content", Selector: "h1", Pos: types.Position{File: "a.yml", Line: 4, Column: 5}},
+			{Type: "assert", AssertType: "visible_text", Selector: "h1"},
+			{Type: "store", Variable: "link", Source: "attribute", Selector: "a"},
+			{Type: "store", Variable: "title", Source: "eval", Expression: "document.title"},
+			{Type: "select", Selector: "#size", OptionIndexes: []int{0}},
+			{Type: "assert", AssertType: "selected", Selector: "#size", OptionValues: []string{"m"}, OptionLabels: []string{"M"}},
+			{Type: "upload", Selector: "#avatar"},
+			{Type: "wait", Sleep: 100},
+			{Type: "wait", URL: "**/done", State: "hidden"},
+			{Type: "screenshot", Selector: "#chart", FullPage: true},
+			{Type: "assert", AssertType: "count", Selector: "li"},
+			{Type: "assert", AssertType: "count", Selector: "li", Equals: "many"},
+			{Type: "assert", AssertType: "attribute", Selector: "a", Attribute: "href", OptionValues: []string{"/a", "/b"}},
+			{Type: "assert", AssertType: "title", Value: "Home", Not: true},
+			{Type: "assert", AssertType: "url", Matches: "/users/(\\d+"},
+			{Type: "assert", AssertType: "text_content", Selector: "h1", Contains: "Hi", StartsWith: "H"},
+			{Type: "assert", AssertType: "url", Matches: "/users/(${ID}"},
+			{Type: "retry_until"},
+			{Type: "retry_until", Steps: []types.Step{
+				{Type: "click", Selector: "#refresh"},
+				{Type: "fill", Selector: "#q", Pos: types.Position{File: "a.yml", Line: 30, Column: 9}},
+			}},
+			{Type: "click", Selector: "#accept", If: &types.Condition{Exists: "#banner", Visible: "#banner"}},
+			{Type: "click", Selector: "#accept", Unless: &types.Condition{URL: "/admin", Equals: &production}},
+			{Type: "goto", URL: "/", If: &types.Condition{URLMatches: "/users/(\\d+"}},
+			{Type: "if", If: &types.Condition{Variable: "ENV", Equals: &production}},
+			{Type: "if", Unless: &types.Condition{Variable: "ENV"}, Steps: []types.Step{{Type: "click", Selector: "#ok"}}, Else: []types.Step{
+				{Type: "fill", Selector: "#q", Pos: types.Position{File: "a.yml", Line: 40, Column: 9}},
+			}},
+			{Type: "wait"},
+		},
+	}
+
+	errs := Validate(scenario)
+	expected := []string{
+		`a.yml:4:5: assert text_content "h1": text_content assertion requires exactly one of: contains, equals, matches, starts_with, ends_with`,
+		"step 3 failed: unknown assertion type: visible_text (expected one of: text_content, url, exists,",
+		"step 4 failed: store from attribute requires attribute",
+		"step 7 failed: selected assertion requires exactly one of: value, label",
+		"step 8 failed: upload step requires files",
+		"step 10 failed: wait step state requires selector",
+		"step 11 failed: screenshot step cannot combine full_page with selector",
+		"step 12 failed: count assertion requires equals or min or max",
+		`step 13 failed: count assertion equals must be a number, got "many"`,
+		"step 14 failed: attribute assertion requires a single value",
+		`step 16 failed: url assertion: invalid regular expression "/users/(\\d+"`,
+		"step 17 failed: text_content assertion requires exactly one of: contains, equals, matches, starts_with, ends_with",
+		"step 19 failed: retry_until step requires steps",
+		`a.yml:30:9: fill "#q": fill step requires value`,
+		"step 21 failed: condition requires exactly one of: exists, visible, url, url_matches, var",
+		"step 22 failed: condition equals and not_equals require var",
+		"step 23 failed: condition url_matches: invalid regular expression",
+		"step 24 failed: if block requires then steps",
+		`a.yml:40:9: fill "#q": fill step requires value`,
+		"step 26 failed: wait step requires exactly one of: selector, url, load_state, function, sleep",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), expected[i]) {
+			t.Errorf("Expected error %d to be %q, got %q", i, expected[i], err.Error())
+		}
+	}
+}
+
+func TestResolveFiles(t *testing.T) {
+	step := &types.Step{
+		Type:  "upload",
+		Files: []string{"fixtures/avatar.png", "/tmp/report.pdf"},
+		Pos:   types.Position{File: filepath.Join("scenarios", "profile.yml"), Line: 3, Column: 5},
+	}
+
+	paths := resolveFiles(step)
+	expected := []string{filepath.Join("scenarios", "fixtures", "avatar.png"), "/tmp/report.pdf"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/haruotsu/ezpw/internal/vars"
+	"github.com/haruotsu/ezpw/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// scenarioParser holds the state shared across a scenario file and the files
+// it includes: registered step groups and the chains used to detect cycles.
+type scenarioParser struct {
+	groups   map[string]*stepGroup
+	includes []string // absolute paths of the files currently being parsed
+	using    []string // names of the groups currently being expanded
+	errs     ErrorList
+}
+
+// stepGroup is a named, parameterized list of steps declared under groups:
+type stepGroup struct {
+	steps  *yaml.Node
+	name   string
+	file   string
+	params []string
+	pos    types.Position
+}
+
+func newScenarioParser() *scenarioParser {
+	return &scenarioParser{groups: make(map[string]*stepGroup)}
+}
+
+// registerGroups records the step groups declared in a groups: block. A file
+// included more than once registers its groups only the first time.
+//
+//	groups:
+//	  login:
+//	    params: [user, password]
+//	    steps:
+//	      - fill: {selector: "#user", value: "${user}"}
+func (p *scenarioParser) registerGroups(value *yaml.Node, file string) {
+	if value.Kind != yaml.MappingNode {
+		p.addError(errorAt(value, file, "groups must be a mapping of group names to definitions"))
+		return
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		nameNode, definition := value.Content[i], value.Content[i+1]
+		if existing, ok := p.groups[nameNode.Value]; ok {
+			if existing.pos == position(nameNode, file) {
+				// The same file included again, e.g. by two included files
+				continue
+			}
+			p.addError(errorAt(nameNode, file, "group %q is already defined at %s", nameNode.Value, existing.pos))
+			continue
+		}
+
+		group, err := p.parseGroup(nameNode, definition, file)
+		if err != nil {
+			p.addError(err)
+			continue
+		}
+		p.groups[group.name] = group
+	}
+}
+
+// parseGroup converts a single group definition
+func (p *scenarioParser) parseGroup(nameNode, definition *yaml.Node, file string) (*stepGroup, error) {
+	name := nameNode.Value
+	if definition.Kind != yaml.MappingNode {
+		return nil, errorAt(definition, file, "group %q must be a mapping with steps", name)
+	}
+	p.checkKeys(definition, file, fmt.Sprintf("group %q", name), []string{keyParams, keySteps})
+
+	group := &stepGroup{name: name, file: file, pos: position(nameNode, file)}
+
+	steps := mappingValue(definition, keySteps)
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil, errorAt(definition, file, "group %q requires a list of steps", name)
+	}
+	group.steps = steps
+
+	if params := mappingValue(definition, keyParams); params != nil {
+		if params.Kind != yaml.SequenceNode {
+			return nil, errorAt(params, file, "params of group %q must be a list of names", name)
+		}
+		for _, param := range params.Content {
+			if param.Kind != yaml.ScalarNode {
+				return nil, errorAt(param, file, "params of group %q must be a list of names", name)
+			}
+			group.params = append(group.params, param.Value)
+		}
+	}
+
+	return group, nil
+}
+
+// includeFile parses the file referenced by an include step and returns its
+// steps. The path is relative to the directory of the including file. Groups
+// declared in the included file become available to the including file.
+// Errors in the included file are reported at both the include step and the
+// included location.
+func (p *scenarioParser) includeFile(stepNode *yaml.Node, fromFile string) []types.Step {
+	p.checkKeys(stepNode, fromFile, "include step", []string{stepTypeInclude})
+
+	value := mappingValue(stepNode, stepTypeInclude)
+	if value.Kind != yaml.ScalarNode || value.Value == "" {
+		p.addError(errorAt(value, fromFile, "include requires a file path"))
+		return nil
+	}
+	includePath := value.Value
+
+	resolved := includePath
+	if !filepath.IsAbs(resolved) && fromFile != "" {
+		resolved = filepath.Join(filepath.Dir(fromFile), includePath)
+	}
+
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		p.addError(errorAt(stepNode, fromFile, "include %q: %w", includePath, err))
+		return nil
+	}
+	for _, active := range p.includes {
+		if active == absPath {
+			p.addError(errorAt(stepNode, fromFile, "include %q: include cycle detected: %s",
+				includePath, strings.Join(append(p.includes, absPath), " -> ")))
+			return nil
+		}
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		p.addError(errorAt(stepNode, fromFile, "include %q: failed to read file: %w", includePath, err))
+		return nil
+	}
+
+	root, err := parseDocument(content, resolved)
+	if err != nil {
+		p.addError(errorAt(stepNode, fromFile, "include %q: %w", includePath, err))
+		return nil
+	}
+
+	p.includes = append(p.includes, absPath)
+	defer func() { p.includes = p.includes[:len(p.includes)-1] }()
+
+	errCount := len(p.errs)
+	defer p.wrapErrors(errCount, stepNode, fromFile, "include %q", includePath)
+
+	p.checkIncludedKeys(root, resolved)
+	if groups := mappingValue(root, keyGroups); groups != nil {
+		p.registerGroups(groups, resolved)
+	}
+
+	var steps []types.Step
+	if stepList := mappingValue(root, keySteps); stepList != nil {
+		steps = p.convertSteps(stepList, resolved)
+	}
+	return steps
+}
+
+// includedKeys are the keys read from an included file
+var includedKeys = []string{keyGroups, keySteps}
+
+// checkIncludedKeys records an error for every key of an included file other
+// than groups and steps. Scenario settings such as vars apply to the scenario
+// being run, so they are rejected rather than silently ignored.
+func (p *scenarioParser) checkIncludedKeys(root *yaml.Node, file string) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		switch {
+		case containsString(includedKeys, key.Value):
+		case containsString(scenarioKeys, key.Value):
+			p.addError(errorAt(key, file, "%s is not allowed in an included file, which only provides groups and steps", key.Value))
+		default:
+			p.addError(errorAt(key, file, "unknown key %q in included file%s", key.Value, Suggestion(key.Value, includedKeys)))
+		}
+	}
+}
+
+// useGroup expands a use step into the steps of the named group, replacing
+// ${param} references with the values given under with:
+func (p *scenarioParser) useGroup(stepNode *yaml.Node, file string) []types.Step {
+	p.checkKeys(stepNode, file, "use step", []string{stepTypeUse, keyWith})
+
+	value := mappingValue(stepNode, stepTypeUse)
+	if value.Kind != yaml.ScalarNode || value.Value == "" {
+		p.addError(errorAt(value, file, "use requires a group name"))
+		return nil
+	}
+	name := value.Value
+
+	group, ok := p.groups[name]
+	if !ok {
+		p.addError(errorAt(stepNode, file, "use %q: unknown step group%s", name, Suggestion(name, p.groupNames())))
+		return nil
+	}
+
+	for _, active := range p.using {
+		if active == name {
+			p.addError(errorAt(stepNode, file, "use %q: group cycle detected: %s",
+				name, strings.Join(append(p.using, name), " -> ")))
+			return nil
+		}
+	}
+
+	params, err := group.bind(stepNode, mappingValue(stepNode, keyWith), file)
+	if err != nil {
+		p.addError(err)
+		return nil
+	}
+
+	p.using = append(p.using, name)
+	defer func() { p.using = p.using[:len(p.using)-1] }()
+
+	errCount := len(p.errs)
+	defer p.wrapErrors(errCount, stepNode, file, "use %q", name)
+
+	return p.convertSteps(substituteParams(group.steps, params), group.file)
+}
+
+// wrapErrors locates the errors recorded since errCount at stepNode, keeping
+// their original location in the message, e.g.
+// `main.yml:3:5: include "login.yml": login.yml:4:5: ...`
+func (p *scenarioParser) wrapErrors(errCount int, stepNode *yaml.Node, file, format string, args ...interface{}) {
+	context := fmt.Sprintf(format, args...)
+	for i := errCount; i < len(p.errs); i++ {
+		p.errs[i] = &ParseError{Pos: position(stepNode, file), Err: fmt.Errorf("%s: %w", context, p.errs[i])}
+	}
+}
+
+// groupNames returns the names of the registered step groups
+func (p *scenarioParser) groupNames() []string {
+	names := make([]string, 0, len(p.groups))
+	for name := range p.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bind checks the arguments given under with: against the group's params.
+// Errors are located at the argument or, for missing arguments, at the use step.
+func (g *stepGroup) bind(use, with *yaml.Node, file string) (map[string]string, error) {
+	params := make(map[string]string)
+	if with != nil {
+		if with.Kind != yaml.MappingNode {
+			return nil, errorAt(with, file, "with must be a mapping of parameter names to values")
+		}
+		for i := 0; i+1 < len(with.Content); i += 2 {
+			argName, argValue := with.Content[i], with.Content[i+1]
+			if !g.hasParam(argName.Value) {
+				return nil, errorAt(argName, file, "unknown parameter %q (group %q accepts: %s)",
+					argName.Value, g.name, strings.Join(g.params, ", "))
+			}
+			if argValue.Kind != yaml.ScalarNode {
+				return nil, errorAt(argValue, file, "parameter %q must be a scalar value", argName.Value)
+			}
+			params[argName.Value] = argValue.Value
+		}
+	}
+
+	var missing []string
+	for _, param := range g.params {
+		if _, ok := params[param]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errorAt(use, file, "use %q: missing parameters: %s", g.name, strings.Join(missing, ", "))
+	}
+
+	return params, nil
+}
+
+func (g *stepGroup) hasParam(name string) bool {
+	for _, param := range g.params {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// substituteParams returns a deep copy of a YAML node with ${param} references
+// replaced in every scalar. Other ${...} references are kept for execution time.
+// Line and column information is preserved.
+func substituteParams(node *yaml.Node, params map[string]string) *yaml.Node {
+	copied := *node
+	if node.Kind == yaml.ScalarNode {
+		copied.Value = vars.Substitute(node.Value, params)
+		return &copied
+	}
+
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = substituteParams(child, params)
+	}
+	return &copied
+}
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeScenarioFiles writes the given files into a temporary directory and returns it
+func writeScenarioFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestParseFileInclude(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{
+		"main.yml": `
+desc: Include test
+steps:
+  - include: common/login.yml
+  - assert:
+      type: url
+      contains: "/dashboard"
+`,
+		"common/login.yml": `
+steps:
+  - goto: "${BASE_URL}/login"
+  - include: submit.yml
+`,
+		"common/submit.yml": `
+steps:
+  - click:
+      selector: "button[type='submit']"
+`,
+	})
+
+	scenario, err := ParseFile(filepath.Join(dir, "main.yml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(scenario.Steps) != 3 {
+		t.Fatalf("Expected 3 steps after expanding includes, got %d", len(scenario.Steps))
+	}
+	if scenario.Steps[0].Type != "goto" || scenario.Steps[1].Type != "click" || scenario.Steps[2].Type != "assert" {
+		t.Errorf("Unexpected step order: %s, %s, %s", scenario.Steps[0].Type, scenario.Steps[1].Type, scenario.Steps[2].Type)
+	}
+	if scenario.File != filepath.Join(dir, "main.yml") {
+		t.Errorf("Expected scenario file to be recorded, got '%s'", scenario.File)
+	}
+}
+
+func TestParseFileStepGroups(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{
+		"main.yml": `
+desc: Group test
+steps:
+  - include: library.yml
+  - use: login
+    with:
+      user: alice
+      password: secret
+`,
+		"library.yml": `
+groups:
+  login:
+    params: [user, password]
+    steps:
+      - goto: "${BASE_URL}/login"
+      - fill:
+          selector: "#user"
+          value: "${user}"
+      - fill:
+          selector: "#password"
+          value: "${password}"
+`,
+	})
+
+	scenario, err := ParseFile(filepath.Join(dir, "main.yml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(scenario.Steps) != 3 {
+		t.Fatalf("Expected 3 steps after expanding group, got %d", len(scenario.Steps))
+	}
+	// Parameters are substituted, other references are kept for execution time
+	if scenario.Steps[0].URL != "${BASE_URL}/login" {
+		t.Errorf("Expected BASE_URL reference to be kept, got '%s'", scenario.Steps[0].URL)
+	}
+	if scenario.Steps[1].Value != "alice" || scenario.Steps[2].Value != "secret" {
+		t.Errorf("Expected parameters to be substituted, got '%s' and '%s'", scenario.Steps[1].Value, scenario.Steps[2].Value)
+	}
+}
+
+func TestParseFileIncludeTwice(t *testing.T) {
+	library := "groups:\n  login:\n    steps:\n      - goto: x\nsteps:\n  - goto: y\n"
+	tests := []struct {
+		files map[string]string
+		name  string
+		steps int
+	}{
+		{
+			name: "same file included twice",
+			files: map[string]string{
+				"main.yml":   "steps:\n  - include: common.yml\n  - use: login\n  - include: common.yml\n",
+				"common.yml": library,
+			},
+			steps: 3,
+		},
+		{
+			name: "diamond",
+			files: map[string]string{
+				"main.yml":          "steps:\n  - include: a.yml\n  - include: b.yml\n  - use: login\n",
+				"a.yml":             "steps:\n  - include: shared/common.yml\n",
+				"b.yml":             "steps:\n  - include: shared/../shared/common.yml\n",
+				"shared/common.yml": library,
+			},
+			steps: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeScenarioFiles(t, tt.files)
+
+			scenario, err := ParseFile(filepath.Join(dir, "main.yml"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(scenario.Steps) != tt.steps {
+				t.Errorf("Expected %d steps, got %d", tt.steps, len(scenario.Steps))
+			}
+		})
+	}
+}
+
+func TestParseFileIncludeErrors(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		name     string
+		contains []string
+	}{
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"main.yml": "steps:\n  - include: a.yml\n",
+				"a.yml":    "steps:\n  - include: main.yml\n",
+			},
+			contains: []string{"include cycle detected"},
+		},
+		{
+			name: "group cycle",
+			files: map[string]string{
+				"main.yml": "groups:\n  loop:\n    steps:\n      - use: loop\nsteps:\n  - use: loop\n",
+			},
+			contains: []string{"group cycle detected: loop -> loop"},
+		},
+		{
+			name: "unknown group",
+			files: map[string]string{
+				"main.yml": "steps:\n  - use: missing\n",
+			},
+			contains: []string{`use "missing": unknown step group`},
+		},
+		{
+			name: "missing parameter",
+			files: map[string]string{
+				"main.yml": "groups:\n  login:\n    params: [user]\n    steps:\n      - goto: x\nsteps:\n  - use: login\n",
+			},
+			contains: []string{"missing parameters: user"},
+		},
+		{
+			name: "unknown parameter",
+			files: map[string]string{
+				"main.yml": "groups:\n  login:\n    steps:\n      - goto: x\nsteps:\n  - use: login\n    with:\n      user: alice\n",
+			},
+			contains: []string{`unknown parameter "user"`},
+		},
+		{
+			name: "scenario settings in included file",
+			files: map[string]string{
+				"main.yml":   "steps:\n  - include: common.yml\n",
+				"common.yml": "vars:\n  USER: alice\nstepz:\n  - goto: x\n",
+			},
+			contains: []string{
+				"common.yml:1:1: vars is not allowed in an included file, which only provides groups and steps",
+				`common.yml:3:1: unknown key "stepz" in included file (did you mean "steps"?)`,
+			},
+		},
+		{
+			name: "group defined in two files",
+			files: map[string]string{
+				"main.yml": "steps:\n  - include: a.yml\n  - include: b.yml\n",
+				"a.yml":    "groups:\n  login:\n    steps:\n      - goto: x\n",
+				"b.yml":    "groups:\n  login:\n    steps:\n      - goto: y\n",
+			},
+			contains: []string{`b.yml:2:3: group "login" is already defined at `},
+		},
+		{
+			name: "error in included file points to both files",
+			files: map[string]string{
+				"main.yml":  "steps:\n  - goto: x\n  - include: login.yml\n",
+				"login.yml": "steps:\n  - goto: x\n  - bogus: true\n",
+			},
+			contains: []string{"main.yml:3:5: include \"login.yml\": ", "login.yml:3:5: no valid step type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeScenarioFiles(t, tt.files)
+
+			_, err := ParseFile(filepath.Join(dir, "main.yml"))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			for _, expected := range tt.contains {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
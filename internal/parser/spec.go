@@ -0,0 +1,807 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// valueKind is the YAML value type accepted by a key
+type valueKind string
+
+const (
+	kindString    valueKind = "string"
+	kindInt       valueKind = "integer"
+	kindBool      valueKind = "boolean"
+	kindList      valueKind = "list"      // list of strings
+	kindStrings   valueKind = "strings"   // a string or a list of strings
+	kindInts      valueKind = "integers"  // an integer or a list of integers
+	kindPoint     valueKind = "point"     // mapping with numeric x and y
+	kindSteps     valueKind = "steps"     // list of steps
+	kindCondition valueKind = "condition" // mapping checked by if and unless
+)
+
+// fieldSpec describes a key accepted by a step
+type fieldSpec struct {
+	set func(step *types.Step, node *yaml.Node) error
+	// steps returns the nested steps of a steps field, which the parser
+	// converts like top-level steps instead of calling set
+	steps       func(step *types.Step) *[]types.Step
+	minimum     *int
+	name        string
+	kind        valueKind
+	description string
+	enum        []string
+	required    bool
+}
+
+// stepSpec describes a step type and the keys it accepts
+type stepSpec struct {
+	// scalar is the field a scalar value such as `goto: "https://..."` is
+	// assigned to; nil when the step only accepts a mapping
+	scalar      *fieldSpec
+	name        string
+	description string
+	aliases     []string
+	// fields are the keys accepted when the value is a mapping;
+	// nil when the step only accepts a scalar
+	fields []fieldSpec
+	// requireAny lists fields of which at least one must be given
+	requireAny []string
+	// requireOne lists fields of which exactly one must be given
+	requireOne []string
+}
+
+// stepSpecs lists every step type supported in scenario files
+var stepSpecs = []stepSpec{
+	{
+		name:        stepTypeGoto,
+		description: "Navigate to a URL",
+		scalar:      stringSpec("url", "URL to navigate to", func(s *types.Step) *string { return &s.URL }).asScalar(),
+	},
+	{
+		name:        stepTypeClick,
+		description: "Click an element, or a position on the page",
+		fields: append(clickFields(),
+			stringSpec("button", "Mouse button (default: left)", func(s *types.Step) *string { return &s.Button }).
+				oneOf("left", "right", "middle")),
+		requireAny: []string{"selector", "position"},
+	},
+	{
+		name:        stepTypeDblclick,
+		description: "Double-click an element, or a position on the page",
+		fields:      clickFields(),
+		requireAny:  []string{"selector", "position"},
+	},
+	{
+		name:        stepTypeRightClick,
+		description: "Right-click an element, or a position on the page",
+		fields:      clickFields(),
+		requireAny:  []string{"selector", "position"},
+	},
+	{
+		name:        stepTypeHover,
+		description: "Move the mouse over an element",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the element to hover over", func(s *types.Step) *string { return &s.Selector }).require(),
+		},
+	},
+	{
+		name:        stepTypeDrag,
+		description: "Drag an element and drop it onto another",
+		fields: []fieldSpec{
+			stringSpec("from", "Selector of the element to drag", func(s *types.Step) *string { return &s.Selector }).require(),
+			stringSpec("to", "Selector of the element to drop onto", func(s *types.Step) *string { return &s.Target }).require(),
+		},
+	},
+	{
+		name:        stepTypeFill,
+		description: "Fill an input element with a value",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the input", func(s *types.Step) *string { return &s.Selector }).require(),
+			stringSpec("value", "Value to fill in", func(s *types.Step) *string { return &s.Value }).require(),
+		},
+	},
+	{
+		name:        stepTypeSelect,
+		description: "Select options of a <select> element by value, label or index",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the <select> element", func(s *types.Step) *string { return &s.Selector }).require(),
+			stringsSpec("value", "Value of the option to select, or a list for a multiple select",
+				func(s *types.Step) *[]string { return &s.OptionValues }),
+			stringsSpec("label", "Label of the option to select, or a list for a multiple select",
+				func(s *types.Step) *[]string { return &s.OptionLabels }),
+			intsSpec("index", "Zero-based index of the option to select, or a list for a multiple select",
+				func(s *types.Step) *[]int { return &s.OptionIndexes }),
+		},
+		requireOne: []string{"value", "label", "index"},
+	},
+	{
+		name:        stepTypeCheck,
+		description: "Check a checkbox or radio button",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the checkbox or radio button", func(s *types.Step) *string { return &s.Selector }).require(),
+		},
+	},
+	{
+		name:        stepTypeUncheck,
+		description: "Uncheck a checkbox",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the checkbox", func(s *types.Step) *string { return &s.Selector }).require(),
+		},
+	},
+	{
+		name:        stepTypeUpload,
+		description: "Set the files of an <input type=\"file\">",
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the file input", func(s *types.Step) *string { return &s.Selector }).require(),
+			stringsSpec("files", "Path or list of paths, relative to the scenario file; an empty list clears the input",
+				func(s *types.Step) *[]string { return &s.Files }).require(),
+		},
+	},
+	{
+		name:        stepTypePress,
+		description: "Press a key or chord such as Enter or Control+K",
+		scalar:      stringSpec("key", "Key or chord to press", func(s *types.Step) *string { return &s.Key }).asScalar(),
+		fields: []fieldSpec{
+			stringSpec("key", "Key or chord to press", func(s *types.Step) *string { return &s.Key }).require(),
+			stringSpec("selector", "Element to press the key on (default: the focused element)", func(s *types.Step) *string { return &s.Selector }),
+		},
+	},
+	{
+		name:        stepTypeType,
+		description: "Type text one key at a time",
+		scalar:      stringSpec("text", "Text to type", func(s *types.Step) *string { return &s.Value }).asScalar(),
+		fields: []fieldSpec{
+			stringSpec("text", "Text to type", func(s *types.Step) *string { return &s.Value }).require(),
+			stringSpec("selector", "Element to type into (default: the focused element)", func(s *types.Step) *string { return &s.Selector }),
+			intSpec("delay", "Delay between keys in milliseconds", func(s *types.Step) *int { return &s.Delay }).nonNegative(),
+		},
+	},
+	{
+		name:        stepTypeKeyDown,
+		description: "Hold down a key until a key_up step",
+		scalar:      stringSpec("key", "Key to hold down", func(s *types.Step) *string { return &s.Key }).asScalar(),
+	},
+	{
+		name:        stepTypeKeyUp,
+		description: "Release a key held down by key_down",
+		scalar:      stringSpec("key", "Key to release", func(s *types.Step) *string { return &s.Key }).asScalar(),
+	},
+	{
+		name:        stepTypeWait,
+		description: "Wait for an element, URL, load state or JavaScript condition, or sleep for a fixed time",
+		scalar: intSpec("sleep", "Milliseconds to sleep", func(s *types.Step) *int { return &s.Sleep }).
+			positive().asScalar(),
+		fields: []fieldSpec{
+			stringSpec("selector", "Selector of the element to wait for", func(s *types.Step) *string { return &s.Selector }),
+			stringSpec("state", "Element state to wait for with selector (default: visible)", func(s *types.Step) *string { return &s.State }).
+				oneOf("attached", "detached", "visible", "hidden"),
+			stringSpec("url", "URL or glob pattern such as **/dashboard to wait for", func(s *types.Step) *string { return &s.URL }),
+			stringSpec("load_state", "Page load state to wait for", func(s *types.Step) *string { return &s.LoadState }).
+				oneOf("load", "domcontentloaded", "networkidle"),
+			stringSpec("function", "JavaScript expression or function to wait to return a truthy value",
+				func(s *types.Step) *string { return &s.Expression }),
+			intSpec("sleep", "Milliseconds to sleep", func(s *types.Step) *int { return &s.Sleep }).positive(),
+		},
+		requireOne: []string{"selector", "url", "load_state", "function", "sleep"},
+	},
+	{
+		name:        stepTypeScreenshot,
+		description: "Save a PNG screenshot of the viewport, the whole page or an element into the output directory",
+		scalar: stringSpec("path", "File to write, relative to the output directory", func(s *types.Step) *string { return &s.Path }).
+			asScalar(),
+		fields: []fieldSpec{
+			stringSpec("path", "File to write, relative to the output directory (default: named after the scenario and step)",
+				func(s *types.Step) *string { return &s.Path }),
+			stringSpec("selector", "Element to capture instead of the viewport", func(s *types.Step) *string { return &s.Selector }),
+			boolSpec("full_page", "Capture the whole scrollable page instead of the viewport", func(s *types.Step) *bool { return &s.FullPage }),
+		},
+	},
+	{
+		name:        stepTypeAssert,
+		description: "Assert a condition on the page",
+		fields: []fieldSpec{
+			stringSpec("type", "Assertion type", func(s *types.Step) *string { return &s.AssertType }).require(),
+			stringSpec("selector", "Selector of the element to check", func(s *types.Step) *string { return &s.Selector }),
+			stringSpec("contains", "Text expected within the element text, URL or title",
+				func(s *types.Step) *string { return &s.Contains }),
+			stringSpec("equals", "Exact expected text, or number of elements for a count assertion",
+				func(s *types.Step) *string { return &s.Equals }),
+			stringSpec("matches", "Regular expression the text is expected to match", func(s *types.Step) *string { return &s.Matches }),
+			stringSpec("starts_with", "Expected start of the text", func(s *types.Step) *string { return &s.StartsWith }),
+			stringSpec("ends_with", "Expected end of the text", func(s *types.Step) *string { return &s.EndsWith }),
+			boolSpec("normalize_whitespace", "Trim and collapse whitespace before comparing texts",
+				func(s *types.Step) *bool { return &s.NormalizeWhitespace }),
+			boolSpec("ignore_case", "Compare texts case-insensitively", func(s *types.Step) *bool { return &s.IgnoreCase }),
+			expectedValueSpec(),
+			stringsSpec("label", "Expected labels of the selected options",
+				func(s *types.Step) *[]string { return &s.OptionLabels }),
+			stringSpec("name", "Attribute checked by an attribute assertion", func(s *types.Step) *string { return &s.Attribute }),
+			stringSpec("property", "CSS property checked by a css assertion", func(s *types.Step) *string { return &s.Property }),
+			optionalIntSpec("min", "Minimum number of elements for a count assertion", func(s *types.Step) **int { return &s.Min }).
+				nonNegative(),
+			optionalIntSpec("max", "Maximum number of elements for a count assertion", func(s *types.Step) **int { return &s.Max }).
+				nonNegative(),
+			boolSpec("not", "Pass when the assertion does not hold", func(s *types.Step) *bool { return &s.Not }),
+			boolSpec("soft", "On failure, record the failure and continue the scenario", func(s *types.Step) *bool { return &s.Soft }),
+		},
+	},
+	{
+		name:        stepTypeRetryUntil,
+		description: "Run a block of steps again until all of them pass, such as clicking refresh until a status shows",
+		fields: []fieldSpec{
+			stepsSpec("steps", "Steps to run on each attempt", func(s *types.Step) *[]types.Step { return &s.Steps }).require(),
+			intSpec("max_attempts", "Most times to run the steps (default: 3)", func(s *types.Step) *int { return &s.MaxAttempts }).
+				positive(),
+			optionalIntSpec("interval", "Milliseconds to wait between attempts (default: 1000)",
+				func(s *types.Step) **int { return &s.Interval }).nonNegative(),
+		},
+	},
+	{
+		name:        stepTypeStore,
+		aliases:     []string{stepTypeExtract},
+		description: "Save a value from the page into a variable",
+		fields: []fieldSpec{
+			stringSpec("name", "Name of the variable to store the value in", func(s *types.Step) *string { return &s.Variable }).require(),
+			stringSpec("from", "Where to read the value from (default: text)", func(s *types.Step) *string { return &s.Source }).
+				oneOf("text", "value", "attribute", "url", "eval"),
+			stringSpec("selector", "Selector of the element to read", func(s *types.Step) *string { return &s.Selector }),
+			stringSpec("attribute", "Attribute to read when from is attribute", func(s *types.Step) *string { return &s.Attribute }),
+			stringSpec("expression", "JavaScript to evaluate when from is eval", func(s *types.Step) *string { return &s.Expression }),
+		},
+	},
+}
+
+// clickFields returns the keys shared by the click steps
+func clickFields() []fieldSpec {
+	return []fieldSpec{
+		stringSpec("selector", "Selector of the element to click", func(s *types.Step) *string { return &s.Selector }),
+		listSpec("modifiers", "Keys held during the click", func(s *types.Step) *[]string { return &s.Modifiers }).
+			oneOf("Alt", "Control", "ControlOrMeta", "Meta", "Shift"),
+		pointSpec("position", "Point to click, relative to the element or to the page without a selector",
+			func(s *types.Step) **types.Point { return &s.Point }),
+	}
+}
+
+// modifierSpecs lists the keys accepted next to the action key of any step
+var modifierSpecs = []fieldSpec{
+	intSpec(keyTimeout, "Timeout for this step in milliseconds", func(s *types.Step) *int { return &s.Timeout }).nonNegative(),
+	conditionSpec(keyIf, "Run the step only when the condition holds", func(s *types.Step) **types.Condition { return &s.If }),
+	conditionSpec(keyUnless, "Skip the step when the condition holds", func(s *types.Step) **types.Condition { return &s.Unless }),
+}
+
+// conditionChecks are the keys of a condition of which exactly one is given
+var conditionChecks = []string{"exists", "visible", "url", "url_matches", "var"}
+
+// conditionKeys are the keys of an if or unless condition
+var conditionKeys = append(append([]string(nil), conditionChecks...), "equals", "not_equals")
+
+// StepType describes a step type accepted in scenario files
+type StepType struct {
+	// Scalar is the field set by the short form of the step, such as
+	// `goto: "https://..."`; nil when the step only accepts a mapping
+	Scalar      *Field
+	Name        string
+	Description string
+	Aliases     []string
+	// Fields are the keys accepted when the value is a mapping;
+	// nil when the step only accepts a scalar
+	Fields []Field
+	// RequireAny lists fields of which at least one must be given
+	RequireAny []string
+	// RequireOne lists fields of which exactly one must be given
+	RequireOne []string
+}
+
+// Field describes a key accepted by a step
+type Field struct {
+	// Minimum is the smallest accepted value of an integer field, if any
+	Minimum *int
+	Name    string
+	// Kind is "string", "integer", "boolean", "list" (of strings), "strings"
+	// (a string or a list of strings), "integers" (an integer or a list of
+	// integers), "point" (a mapping with x and y), "steps" (a list of steps)
+	// or "condition" (a mapping checked by if and unless)
+	Kind        string
+	Description string
+	Enum        []string
+	Required    bool
+}
+
+// StepTypes returns the step types accepted in scenario files, except the
+// include and use steps which are expanded by the parser
+func StepTypes() []StepType {
+	stepTypes := make([]StepType, len(stepSpecs))
+	for i, spec := range stepSpecs {
+		stepTypes[i] = StepType{
+			Name:        spec.name,
+			Description: spec.description,
+			Aliases:     spec.aliases,
+			RequireAny:  spec.requireAny,
+			RequireOne:  spec.requireOne,
+		}
+		if spec.scalar != nil {
+			scalar := spec.scalar.export()
+			stepTypes[i].Scalar = &scalar
+		}
+		if spec.fields != nil {
+			stepTypes[i].Fields = exportFields(spec.fields)
+		}
+	}
+	return stepTypes
+}
+
+// StepModifiers returns the keys accepted next to the action key of any step
+func StepModifiers() []Field {
+	return exportFields(modifierSpecs)
+}
+
+// ScenarioKeys returns the top-level keys of a scenario file
+func ScenarioKeys() []string {
+	return append([]string(nil), scenarioKeys...)
+}
+
+// ConditionKeys returns the keys of an if or unless condition
+func ConditionKeys() []string {
+	return append([]string(nil), conditionKeys...)
+}
+
+// ConditionChecks returns the condition keys of which exactly one is given
+func ConditionChecks() []string {
+	return append([]string(nil), conditionChecks...)
+}
+
+func exportFields(fields []fieldSpec) []Field {
+	exported := make([]Field, len(fields))
+	for i, field := range fields {
+		exported[i] = field.export()
+	}
+	return exported
+}
+
+func (f fieldSpec) export() Field {
+	return Field{
+		Name:        f.name,
+		Kind:        string(f.kind),
+		Description: f.description,
+		Enum:        f.enum,
+		Required:    f.required,
+		Minimum:     f.minimum,
+	}
+}
+
+// lookupStepSpec returns the spec for a step type name or alias
+func lookupStepSpec(name string) *stepSpec {
+	for i := range stepSpecs {
+		spec := &stepSpecs[i]
+		if spec.name == name {
+			return spec
+		}
+		for _, alias := range spec.aliases {
+			if alias == name {
+				return spec
+			}
+		}
+	}
+	return nil
+}
+
+// lookupField returns the spec of the key name in fields
+func lookupField(fields []fieldSpec, name string) *fieldSpec {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// actionNames returns every step type name and alias, including include and use
+func actionNames() []string {
+	names := []string{stepTypeInclude, stepTypeUse}
+	for _, spec := range stepSpecs {
+		names = append(names, spec.name)
+		names = append(names, spec.aliases...)
+	}
+	return names
+}
+
+// fieldNames returns the names of fields
+func fieldNames(fields []fieldSpec) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.name
+	}
+	return names
+}
+
+// form describes the value shapes accepted by a step for error messages
+func (s *stepSpec) form() string {
+	switch {
+	case s.scalar != nil && s.fields != nil:
+		return "a string or a mapping"
+	case s.scalar != nil:
+		return "a string"
+	default:
+		return "a mapping with keys: " + strings.Join(fieldNames(s.fields), ", ")
+	}
+}
+
+// stringSpec describes a string key stored into the field returned by target
+func stringSpec(name, description string, target func(*types.Step) *string) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindString,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			*target(step) = node.Value
+			return nil
+		},
+	}
+}
+
+// stepsSpec describes a key holding a list of nested steps
+func stepsSpec(name, description string, target func(*types.Step) *[]types.Step) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindSteps,
+		description: description,
+		steps:       target,
+	}
+}
+
+// conditionSpec describes an if or unless condition stored into the field
+// returned by target
+func conditionSpec(name, description string, target func(*types.Step) **types.Condition) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindCondition,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			condition := &types.Condition{}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if !isScalar(value, "") {
+					return fmt.Errorf("%s must be a string, got %s", key.Value, describeNode(value))
+				}
+				text := value.Value
+				switch key.Value {
+				case "exists":
+					condition.Exists = text
+				case "visible":
+					condition.Visible = text
+				case "url":
+					condition.URL = text
+				case "url_matches":
+					condition.URLMatches = text
+				case "var":
+					condition.Variable = text
+				case "equals":
+					condition.Equals = &text
+				case "not_equals":
+					condition.NotEquals = &text
+				default:
+					return fmt.Errorf("unknown key %q in condition%s", key.Value, Suggestion(key.Value, conditionKeys))
+				}
+			}
+			*target(step) = condition
+			return nil
+		},
+	}
+}
+
+// intSpec describes an integer key stored into the field returned by target
+func intSpec(name, description string, target func(*types.Step) *int) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindInt,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			return node.Decode(target(step))
+		},
+	}
+}
+
+// boolSpec describes a boolean key stored into the field returned by target
+func boolSpec(name, description string, target func(*types.Step) *bool) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindBool,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			return node.Decode(target(step))
+		},
+	}
+}
+
+// optionalIntSpec describes an integer key whose absence is told apart from 0
+func optionalIntSpec(name, description string, target func(*types.Step) **int) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindInt,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			var value int
+			if err := node.Decode(&value); err != nil {
+				return err
+			}
+			*target(step) = &value
+			return nil
+		},
+	}
+}
+
+// expectedValueSpec describes the value key of an assert step: the values of
+// the options a selected assertion expects, which may be a list, or the
+// expected value of other assertion types, also stored into Step.Value
+func expectedValueSpec() fieldSpec {
+	field := stringsSpec("value", "Expected value, or values of the selected options",
+		func(s *types.Step) *[]string { return &s.OptionValues })
+	setValues := field.set
+	field.set = func(step *types.Step, node *yaml.Node) error {
+		if err := setValues(step, node); err != nil {
+			return err
+		}
+		if len(step.OptionValues) == 1 {
+			step.Value = step.OptionValues[0]
+		}
+		return nil
+	}
+	return field
+}
+
+// listSpec describes a key holding a list of strings
+func listSpec(name, description string, target func(*types.Step) *[]string) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindList,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			return node.Decode(target(step))
+		},
+	}
+}
+
+// stringsSpec describes a key holding a string or a list of strings
+func stringsSpec(name, description string, target func(*types.Step) *[]string) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindStrings,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			values := []string{}
+			if node.Kind == yaml.ScalarNode {
+				values = append(values, node.Value)
+			} else if err := node.Decode(&values); err != nil {
+				return err
+			}
+			*target(step) = values
+			return nil
+		},
+	}
+}
+
+// intsSpec describes a key holding an integer or a list of integers
+func intsSpec(name, description string, target func(*types.Step) *[]int) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindInts,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			values := []int{}
+			if node.Kind == yaml.ScalarNode {
+				var value int
+				if err := node.Decode(&value); err != nil {
+					return err
+				}
+				values = append(values, value)
+			} else if err := node.Decode(&values); err != nil {
+				return err
+			}
+			*target(step) = values
+			return nil
+		},
+	}
+}
+
+// pointSpec describes a key holding a point such as {x: 10, y: 20}
+func pointSpec(name, description string, target func(*types.Step) **types.Point) fieldSpec {
+	return fieldSpec{
+		name:        name,
+		kind:        kindPoint,
+		description: description,
+		set: func(step *types.Step, node *yaml.Node) error {
+			var point types.Point
+			if err := node.Decode(&point); err != nil {
+				return err
+			}
+			*target(step) = &point
+			return nil
+		},
+	}
+}
+
+// require marks the field as mandatory
+func (f fieldSpec) require() fieldSpec {
+	f.required = true
+	return f
+}
+
+// asScalar returns the field for use as the scalar form of a step
+func (f fieldSpec) asScalar() *fieldSpec {
+	return &f
+}
+
+// oneOf restricts the field to the given values
+func (f fieldSpec) oneOf(values ...string) fieldSpec {
+	f.enum = values
+	return f
+}
+
+// positive rejects integers less than 1 for the field
+func (f fieldSpec) positive() fieldSpec {
+	one := 1
+	f.minimum = &one
+	set := f.set
+	name := f.name
+	f.set = func(step *types.Step, node *yaml.Node) error {
+		var value int
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		if value < 1 {
+			return fmt.Errorf("%s must be at least 1, got %d", name, value)
+		}
+		return set(step, node)
+	}
+	return f
+}
+
+// nonNegative rejects negative integers for the field
+func (f fieldSpec) nonNegative() fieldSpec {
+	zero := 0
+	f.minimum = &zero
+	set := f.set
+	name := f.name
+	f.set = func(step *types.Step, node *yaml.Node) error {
+		var value int
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", name, value)
+		}
+		return set(step, node)
+	}
+	return f
+}
+
+// decodeField validates node against field and stores it into step
+func decodeField(step *types.Step, field *fieldSpec, node *yaml.Node, file string) error {
+	if err := checkKind(field, node, file); err != nil {
+		return err
+	}
+
+	values := []*yaml.Node{node}
+	if field.kind == kindList {
+		values = node.Content
+	}
+	for _, value := range values {
+		if len(field.enum) > 0 && !containsString(field.enum, value.Value) {
+			return errorAt(value, file, "invalid value %q for %s, expected one of: %s%s",
+				value.Value, field.name, strings.Join(field.enum, ", "), Suggestion(value.Value, field.enum))
+		}
+	}
+
+	if err := field.set(step, node); err != nil {
+		return errorAt(node, file, "invalid value for %s: %w", field.name, err)
+	}
+	return nil
+}
+
+// checkKind reports an error when node does not hold a value of the field's kind
+func checkKind(field *fieldSpec, node *yaml.Node, file string) error {
+	valid := false
+	switch field.kind {
+	case kindString:
+		valid = node.Kind == yaml.ScalarNode && node.Tag != "!!null"
+	case kindInt:
+		valid = node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case kindBool:
+		valid = node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	case kindCondition:
+		valid = node.Kind == yaml.MappingNode
+	case kindList:
+		valid = checkItems(node, "")
+	case kindStrings:
+		valid = isScalar(node, "") || checkItems(node, "")
+	case kindInts:
+		valid = isScalar(node, "!!int") || checkItems(node, "!!int")
+	case kindPoint:
+		valid = node.Kind == yaml.MappingNode && len(node.Content) == 4
+		for _, axis := range []string{"x", "y"} {
+			value := mappingValue(node, axis)
+			valid = valid && value != nil && (value.Tag == "!!int" || value.Tag == "!!float")
+		}
+	}
+
+	if !valid {
+		return errorAt(node, file, "%s must be %s, got %s", field.name, kindDescription(field.kind), describeNode(node))
+	}
+	return nil
+}
+
+// isScalar reports whether node is a non-null scalar with the given tag,
+// or with any tag when tag is empty
+func isScalar(node *yaml.Node, tag string) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag != "!!null" && (tag == "" || node.Tag == tag)
+}
+
+// checkItems reports whether node is a list of scalars with the given tag,
+// or with any tag when tag is empty
+func checkItems(node *yaml.Node, tag string) bool {
+	if node.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, item := range node.Content {
+		if !isScalar(item, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// kindDescription names a value kind for error messages
+func kindDescription(kind valueKind) string {
+	switch kind {
+	case kindList:
+		return "a list of strings"
+	case kindStrings:
+		return "a string or a list of strings"
+	case kindInts:
+		return "an integer or a list of integers"
+	case kindPoint:
+		return "a mapping with numeric x and y"
+	case kindCondition:
+		return "a mapping with one of: " + strings.Join(conditionChecks, ", ")
+	default:
+		return article(kind)
+	}
+}
+
+// article prefixes a value kind with "a" or "an"
+func article(kind valueKind) string {
+	if strings.IndexAny(string(kind)[:1], "aeiou") == 0 {
+		return "an " + string(kind)
+	}
+	return "a " + string(kind)
+}
+
+// describeNode names the type of a YAML node for error messages
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "nothing"
+		}
+		return fmt.Sprintf("%q", node.Value)
+	default:
+		return "an unsupported value"
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package parser
+
+import "fmt"
+
+// Suggestion returns a ` (did you mean "x"?)` hint naming the candidate
+// closest to word, or an empty string when no candidate is close enough
+func Suggestion(word string, candidates []string) string {
+	best := ""
+	bestDistance := 0
+	for _, candidate := range candidates {
+		distance := levenshtein(word, candidate)
+		if best == "" || distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	// Allow roughly one typo per three characters, and at least two
+	maxDistance := len(word) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if best == "" || bestDistance > maxDistance || bestDistance >= len(word) {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,42 @@
+package parser
+
+import "testing"
+
+func TestSuggestion(t *testing.T) {
+	candidates := []string{"selector", "value", "timeout"}
+
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{"selecter", ` (did you mean "selector"?)`},
+		{"vlaue", ` (did you mean "value"?)`},
+		{"timout", ` (did you mean "timeout"?)`},
+		{"url", ""},
+		{"x", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Suggestion(tt.word, candidates); got != tt.expected {
+			t.Errorf("Suggestion(%q) = %q, expected %q", tt.word, got, tt.expected)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"click", "clik", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
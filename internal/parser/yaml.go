@@ -1,157 +1,506 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/haruotsu/ezpw/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	stepTypeGoto   = "goto"
-	stepTypeClick  = "click"
-	stepTypeFill   = "fill"
-	stepTypeAssert = "assert"
+	stepTypeGoto       = "goto"
+	stepTypeClick      = "click"
+	stepTypeFill       = "fill"
+	stepTypeAssert     = "assert"
+	stepTypeStore      = "store"
+	stepTypeExtract    = "extract" // Alias for store
+	stepTypeHover      = "hover"
+	stepTypeDblclick   = "dblclick"
+	stepTypeRightClick = "right_click"
+	stepTypeDrag       = "drag"
+	stepTypeSelect     = "select"
+	stepTypeCheck      = "check"
+	stepTypeUncheck    = "uncheck"
+	stepTypeUpload     = "upload"
+	stepTypePress      = "press"
+	stepTypeType       = "type"
+	stepTypeKeyDown    = "key_down"
+	stepTypeKeyUp      = "key_up"
+	stepTypeWait       = "wait"
+	stepTypeScreenshot = "screenshot"
+	stepTypeRetryUntil = "retry_until"
+	stepTypeIf         = "if"
+	stepTypeInclude    = "include"
+	stepTypeUse        = "use"
+
+	keyDesc    = "desc"
+	keySteps   = "steps"
+	keyTimeout = "timeout"
+	keyVideo   = "video"
+	keySoft    = "soft_assertions"
+	keyRetries = "retries"
+	keyVars    = "vars"
+	keyGroups  = "groups"
+	keyWith    = "with"
+	keyParams  = "params"
+	keyIf      = "if"
+	keyUnless  = "unless"
+	keyThen    = "then"
+	keyElse    = "else"
 )
 
-// ParseYAML parses YAML content and returns a Scenario
+// yamlErrorLine matches the line number in YAML decoder errors
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// scenarioKeys are the top-level keys of a scenario file
+var scenarioKeys = []string{keyDesc, keyVars, keyTimeout, keyRetries, keyVideo, keySoft, keyGroups, keySteps}
+
+// videoModes are the values of the scenario video key
+var videoModes = []string{types.RecordOff, types.RecordOn, types.RecordRetainOnFailure}
+
+// ParseError describes a problem at a position in a scenario file
+type ParseError struct {
+	Err error
+	Pos types.Position
+}
+
+func (e *ParseError) Error() string {
+	if e.Pos.File == "" && !e.Pos.IsValid() {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Pos, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList is every problem found in a scenario file, in the order found
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ParseYAML parses YAML content and returns a Scenario.
+// Included files are resolved relative to the current working directory.
+// Validation problems are returned together as an ErrorList, alongside the
+// parts of the scenario that could be read.
 func ParseYAML(reader io.Reader) (*types.Scenario, error) {
 	content, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read YAML content: %w", err)
 	}
 
-	if len(content) == 0 {
-		return nil, fmt.Errorf("empty YAML content")
-	}
+	return newScenarioParser().parse(content, "")
+}
 
-	var rawScenario map[string]interface{}
-	err = yaml.Unmarshal(content, &rawScenario)
+// ParseFile parses the scenario file at path and returns a Scenario.
+// Included files are resolved relative to the directory of path.
+// Validation problems are returned together as an ErrorList, alongside the
+// parts of the scenario that could be read.
+func ParseFile(path string) (*types.Scenario, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	p := newScenarioParser()
+	if absPath, err := filepath.Abs(path); err == nil {
+		p.includes = append(p.includes, absPath)
 	}
 
-	scenario, err := convertToScenario(rawScenario)
+	scenario, err := p.parse(content, path)
+	if scenario != nil {
+		scenario.File = path
+	}
+	return scenario, err
+}
+
+// parse converts YAML content read from file into a Scenario. Steps with
+// problems are left out of the scenario returned with the ErrorList.
+func (p *scenarioParser) parse(content []byte, file string) (*types.Scenario, error) {
+	root, err := parseDocument(content, file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert to scenario: %w", err)
+		return nil, err
 	}
 
+	scenario := p.convertToScenario(root, file)
+	if len(p.errs) > 0 {
+		return scenario, p.errs
+	}
 	return scenario, nil
 }
 
-func convertToScenario(rawScenario map[string]interface{}) (*types.Scenario, error) {
+// parseDocument parses YAML content into the root mapping node of its document
+func parseDocument(content []byte, file string) (*yaml.Node, error) {
+	if len(content) == 0 {
+		return nil, &ParseError{Pos: types.Position{File: file}, Err: fmt.Errorf("empty YAML content")}
+	}
+
+	var document yaml.Node
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, syntaxError(err, file)
+	}
+
+	if len(document.Content) == 0 {
+		return nil, &ParseError{Pos: types.Position{File: file}, Err: fmt.Errorf("empty YAML content")}
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errorAt(root, file, "scenario must be a mapping with desc and steps")
+	}
+	return root, nil
+}
+
+// syntaxError converts a YAML syntax error, locating it at the line
+// reported by the YAML decoder when there is one
+func syntaxError(err error, file string) error {
+	pos := types.Position{File: file}
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		pos.Line, _ = strconv.Atoi(match[1])
+		return &ParseError{Pos: pos, Err: fmt.Errorf("failed to parse YAML: %s", match[2])}
+	}
+	return &ParseError{Pos: pos, Err: fmt.Errorf("failed to parse YAML: %w", err)}
+}
+
+func (p *scenarioParser) convertToScenario(root *yaml.Node, file string) *types.Scenario {
 	scenario := &types.Scenario{}
 
+	p.checkKeys(root, file, "scenario", scenarioKeys)
+
 	// Parse description
-	if desc, ok := rawScenario["desc"].(string); ok {
-		scenario.Description = desc
+	if value := mappingValue(root, keyDesc); value != nil {
+		if value.Kind != yaml.ScalarNode {
+			p.addError(errorAt(value, file, "desc must be a string, got %s", describeNode(value)))
+		} else {
+			scenario.Description = value.Value
+		}
 	}
 
-	// Parse steps
-	if stepDataList, ok := rawScenario["steps"].([]interface{}); ok {
-		steps, err := convertSteps(stepDataList)
-		if err != nil {
-			return nil, err
+	// Parse scenario variables
+	if value := mappingValue(root, keyVars); value != nil {
+		scenarioVars, err := parseVars(value, file)
+		p.addError(err)
+		scenario.Vars = scenarioVars
+	}
+
+	// Parse overall scenario deadline
+	if value := mappingValue(root, keyTimeout); value != nil {
+		timeout, err := parseTimeout(value, file)
+		p.addError(err)
+		scenario.Timeout = timeout
+	}
+
+	// Parse how many times to rerun the scenario after a failure, overriding --retries
+	if value := mappingValue(root, keyRetries); value != nil {
+		var retries int
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!int" || value.Decode(&retries) != nil || retries < 0 {
+			p.addError(errorAt(value, file, "retries must be a non-negative integer, got %s", describeNode(value)))
+		} else {
+			scenario.Retries = &retries
 		}
-		scenario.Steps = steps
 	}
 
-	return scenario, nil
+	// Parse video recording mode, overriding --video
+	if value := mappingValue(root, keyVideo); value != nil {
+		if value.Kind != yaml.ScalarNode || !containsString(videoModes, value.Value) {
+			p.addError(errorAt(value, file, "video must be one of: %s, got %s",
+				strings.Join(videoModes, ", "), describeNode(value)))
+		} else {
+			scenario.Video = value.Value
+		}
+	}
+
+	// Parse whether assertion failures let the scenario continue
+	if value := mappingValue(root, keySoft); value != nil {
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!bool" {
+			p.addError(errorAt(value, file, "soft_assertions must be %s, got %s",
+				kindDescription(kindBool), describeNode(value)))
+		} else {
+			p.addError(value.Decode(&scenario.SoftAssertions))
+		}
+	}
+
+	// Register step groups before steps so they can be used anywhere in the file
+	if value := mappingValue(root, keyGroups); value != nil {
+		p.registerGroups(value, file)
+	}
+
+	// Parse steps
+	if value := mappingValue(root, keySteps); value != nil {
+		scenario.Steps = p.convertSteps(value, file)
+	}
+
+	return scenario
 }
 
-// convertSteps converts YAML step data list to Step structs
-func convertSteps(stepDataList []interface{}) ([]types.Step, error) {
-	var steps []types.Step
+// convertSteps converts a YAML step sequence to Step structs,
+// expanding include and use steps in place. Invalid steps are recorded
+// as errors and left out.
+func (p *scenarioParser) convertSteps(stepList *yaml.Node, file string) []types.Step {
+	if stepList.Kind != yaml.SequenceNode {
+		p.addError(errorAt(stepList, file, "steps must be a list, got %s", describeNode(stepList)))
+		return nil
+	}
 
-	for _, stepData := range stepDataList {
-		stepMap, ok := stepData.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid step format")
+	var steps []types.Step
+	for _, stepNode := range stepList.Content {
+		if stepNode.Kind != yaml.MappingNode {
+			p.addError(errorAt(stepNode, file, "invalid step format"))
+			continue
 		}
 
-		step, err := convertStep(stepMap)
-		if err != nil {
-			return nil, err
+		switch {
+		case mappingValue(stepNode, stepTypeInclude) != nil:
+			steps = append(steps, p.includeFile(stepNode, file)...)
+		case mappingValue(stepNode, stepTypeUse) != nil:
+			steps = append(steps, p.useGroup(stepNode, file)...)
+		case mappingValue(stepNode, keyThen) != nil:
+			if step, ok := p.convertIfBlock(stepNode, file); ok {
+				steps = append(steps, step)
+			}
+		default:
+			if step, ok := p.convertStep(stepNode, file); ok {
+				steps = append(steps, step)
+			}
 		}
-
-		steps = append(steps, step)
 	}
 
-	return steps, nil
+	return steps
 }
 
-func convertStep(stepMap map[string]interface{}) (types.Step, error) {
-	step := types.Step{}
+// convertStep validates a step mapping against the step specs and converts it.
+// A step has exactly one action key, such as click, plus optional modifiers
+// such as timeout. It reports false when the step has errors.
+func (p *scenarioParser) convertStep(stepNode *yaml.Node, file string) (types.Step, bool) {
+	step := types.Step{Pos: position(stepNode, file)}
+	errCount := len(p.errs)
 
-	foundValidType := false
-	// Handle different step types
-	for key, value := range stepMap {
-		switch key {
-		case stepTypeGoto:
-			handleGotoStep(&step, value)
-			foundValidType = true
-		case stepTypeClick:
-			handleClickStep(&step, value)
-			foundValidType = true
-		case stepTypeFill:
-			handleFillStep(&step, value)
-			foundValidType = true
-		case stepTypeAssert:
-			handleAssertStep(&step, value)
-			foundValidType = true
+	var actions []string
+	var spec *stepSpec
+	var action *yaml.Node
+	var unknown []*yaml.Node
+	for i := 0; i+1 < len(stepNode.Content); i += 2 {
+		key, value := stepNode.Content[i], stepNode.Content[i+1]
+		if s := lookupStepSpec(key.Value); s != nil {
+			actions = append(actions, key.Value)
+			spec, action = s, value
+			continue
 		}
+		if modifier := lookupField(modifierSpecs, key.Value); modifier != nil {
+			p.addError(decodeField(&step, modifier, value, file))
+			continue
+		}
+		unknown = append(unknown, key)
 	}
 
-	if !foundValidType {
-		return step, fmt.Errorf("no valid step type found in step")
+	candidates := append(actionNames(), fieldNames(modifierSpecs)...)
+	for _, key := range unknown {
+		if len(actions) == 0 {
+			p.addError(errorAt(key, file, "no valid step type found in step: unknown key %q%s",
+				key.Value, Suggestion(key.Value, candidates)))
+		} else {
+			p.addError(errorAt(key, file, "unknown key %q in %s step%s",
+				key.Value, actions[0], Suggestion(key.Value, candidates)))
+		}
+	}
+
+	switch {
+	case len(actions) == 0:
+		if len(unknown) == 0 {
+			p.addError(errorAt(stepNode, file, "no valid step type found in step"))
+		}
+	case len(actions) > 1:
+		p.addError(errorAt(stepNode, file, "step has multiple actions: %s (use one action per step)",
+			strings.Join(actions, ", ")))
+	default:
+		step.Type = spec.name
+		p.decodeAction(&step, spec, action, file)
 	}
 
 	// Set raw data for complex parsing if needed
-	step.Raw = stepMap
+	var raw map[string]interface{}
+	if err := stepNode.Decode(&raw); err == nil {
+		step.Raw = raw
+	}
 
-	return step, nil
+	return step, len(p.errs) == errCount
 }
 
-func handleGotoStep(step *types.Step, value interface{}) {
-	step.Type = stepTypeGoto
-	if url, ok := value.(string); ok {
-		step.URL = url
+// convertIfBlock converts an if block, which runs the steps under then when
+// its if condition holds (or its unless condition does not) and the steps
+// under else otherwise. It reports false when the block has errors.
+func (p *scenarioParser) convertIfBlock(stepNode *yaml.Node, file string) (types.Step, bool) {
+	step := types.Step{Pos: position(stepNode, file), Type: stepTypeIf}
+	errCount := len(p.errs)
+
+	p.checkKeys(stepNode, file, "if block", []string{keyIf, keyUnless, keyThen, keyElse, keyTimeout})
+	for i := 0; i+1 < len(stepNode.Content); i += 2 {
+		key, value := stepNode.Content[i], stepNode.Content[i+1]
+		switch key.Value {
+		case keyThen:
+			step.Steps = p.convertSteps(value, file)
+		case keyElse:
+			step.Else = p.convertSteps(value, file)
+		default:
+			if modifier := lookupField(modifierSpecs, key.Value); modifier != nil {
+				p.addError(decodeField(&step, modifier, value, file))
+			}
+		}
+	}
+	if (step.If == nil) == (step.Unless == nil) {
+		p.addError(errorAt(stepNode, file, "if block requires exactly one of: if, unless"))
 	}
+
+	return step, len(p.errs) == errCount
 }
 
-func handleClickStep(step *types.Step, value interface{}) {
-	step.Type = stepTypeClick
-	if clickData, ok := value.(map[string]interface{}); ok {
-		if selector, ok := clickData["selector"].(string); ok {
-			step.Selector = selector
+// decodeAction validates the value of a step's action key and stores its
+// fields into step
+func (p *scenarioParser) decodeAction(step *types.Step, spec *stepSpec, value *yaml.Node, file string) {
+	switch {
+	case value.Kind == yaml.ScalarNode && spec.scalar != nil:
+		p.addError(decodeField(step, spec.scalar, value, file))
+
+	case value.Kind == yaml.MappingNode && spec.fields != nil:
+		seen := make(map[string]bool, len(spec.fields))
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, fieldValue := value.Content[i], value.Content[i+1]
+			field := lookupField(spec.fields, key.Value)
+			if field == nil {
+				p.addError(errorAt(key, file, "unknown key %q in %s step%s",
+					key.Value, spec.name, Suggestion(key.Value, fieldNames(spec.fields))))
+				continue
+			}
+			seen[field.name] = true
+			if field.kind == kindSteps {
+				*field.steps(step) = p.convertSteps(fieldValue, file)
+				continue
+			}
+			p.addError(decodeField(step, field, fieldValue, file))
+		}
+
+		for _, field := range spec.fields {
+			if field.required && !seen[field.name] {
+				p.addError(errorAt(value, file, "%s step requires %s", spec.name, field.name))
+			}
 		}
+		if len(spec.requireAny) > 0 && countSeen(seen, spec.requireAny) == 0 {
+			p.addError(errorAt(value, file, "%s step requires %s", spec.name, strings.Join(spec.requireAny, " or ")))
+		}
+		if len(spec.requireOne) > 0 && countSeen(seen, spec.requireOne) != 1 {
+			p.addError(errorAt(value, file, "%s step requires exactly one of: %s", spec.name, strings.Join(spec.requireOne, ", ")))
+		}
+
+	default:
+		p.addError(errorAt(value, file, "%s step must be %s, got %s", spec.name, spec.form(), describeNode(value)))
 	}
 }
 
-func handleFillStep(step *types.Step, value interface{}) {
-	step.Type = stepTypeFill
-	if fillData, ok := value.(map[string]interface{}); ok {
-		if selector, ok := fillData["selector"].(string); ok {
-			step.Selector = selector
-		}
-		if val, ok := fillData["value"].(string); ok {
-			step.Value = val
+// countSeen returns how many of names are in seen
+func countSeen(seen map[string]bool, names []string) int {
+	count := 0
+	for _, name := range names {
+		if seen[name] {
+			count++
 		}
 	}
+	return count
+}
+
+// parseTimeout converts a YAML timeout value in milliseconds
+func parseTimeout(value *yaml.Node, file string) (int, error) {
+	var timeout int
+	if value.Kind != yaml.ScalarNode || value.Decode(&timeout) != nil || timeout < 0 {
+		return 0, errorAt(value, file, "timeout must be a non-negative number of milliseconds, got %s", value.Value)
+	}
+	return timeout, nil
 }
 
-func handleAssertStep(step *types.Step, value interface{}) {
-	step.Type = stepTypeAssert
-	if assertData, ok := value.(map[string]interface{}); ok {
-		if assertType, ok := assertData["type"].(string); ok {
-			step.AssertType = assertType
+// parseVars converts a YAML vars block into a map of string values
+func parseVars(value *yaml.Node, file string) (map[string]string, error) {
+	if value.Kind != yaml.MappingNode {
+		return nil, errorAt(value, file, "vars must be a mapping of names to values")
+	}
+
+	result := make(map[string]string, len(value.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		name, raw := value.Content[i], value.Content[i+1]
+		if raw.Kind != yaml.ScalarNode {
+			return nil, errorAt(raw, file, "variable %s must be a scalar value", name.Value)
 		}
-		if contains, ok := assertData["contains"].(string); ok {
-			step.Contains = contains
+		if raw.Tag == "!!null" {
+			result[name.Value] = ""
+			continue
 		}
-		if selector, ok := assertData["selector"].(string); ok {
-			step.Selector = selector
+		result[name.Value] = raw.Value
+	}
+	return result, nil
+}
+
+// mappingValue returns the value node stored under key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// position returns the location of node in file
+func position(node *yaml.Node, file string) types.Position {
+	return types.Position{File: file, Line: node.Line, Column: node.Column}
+}
+
+// errorAt returns a ParseError located at node
+func errorAt(node *yaml.Node, file, format string, args ...interface{}) error {
+	return &ParseError{Pos: position(node, file), Err: fmt.Errorf(format, args...)}
+}
+
+// addError records err, if any, as a problem in the scenario being parsed
+func (p *scenarioParser) addError(err error) {
+	if err == nil {
+		return
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		parseErr = &ParseError{Err: err}
+	}
+	p.errs = append(p.errs, parseErr)
+}
+
+// checkKeys records an error for every key of a mapping node not in allowed
+func (p *scenarioParser) checkKeys(node *yaml.Node, file, context string, allowed []string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !containsString(allowed, key.Value) {
+			p.addError(errorAt(key, file, "unknown key %q in %s%s", key.Value, context, Suggestion(key.Value, allowed)))
 		}
 	}
 }
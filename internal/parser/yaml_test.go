@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/haruotsu/ezpw/pkg/types"
 )
 
 func TestParseYAMLScenario(t *testing.T) {
@@ -98,3 +102,862 @@ func TestParseEmptyYAML(t *testing.T) {
 		t.Error("Expected error for empty YAML, got nil")
 	}
 }
+
+func TestParseTimeouts(t *testing.T) {
+	yamlContent := `
+desc: Timeout test
+timeout: 60000
+steps:
+  - goto: "https://example.com"
+  - click:
+      selector: "#slow"
+    timeout: 5000
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if scenario.Timeout != 60000 {
+		t.Errorf("Expected scenario timeout 60000, got %d", scenario.Timeout)
+	}
+	if scenario.Steps[0].Timeout != 0 {
+		t.Errorf("Expected no timeout on first step, got %d", scenario.Steps[0].Timeout)
+	}
+	if scenario.Steps[1].Timeout != 5000 {
+		t.Errorf("Expected step timeout 5000, got %d", scenario.Steps[1].Timeout)
+	}
+}
+
+func TestParseInvalidTimeout(t *testing.T) {
+	yamlContent := `
+desc: Timeout test
+steps:
+  - goto: "https://example.com"
+    timeout: soon
+`
+
+	_, err := ParseYAML(strings.NewReader(yamlContent))
+	if err == nil {
+		t.Error("Expected error for invalid timeout, got nil")
+	}
+}
+
+func TestParseVideo(t *testing.T) {
+	scenario, err := ParseYAML(strings.NewReader("video: retain-on-failure\nsteps:\n  - goto: x\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if scenario.Video != types.RecordRetainOnFailure {
+		t.Errorf("Expected video mode retain-on-failure, got %q", scenario.Video)
+	}
+
+	_, err = ParseYAML(strings.NewReader("video: always\nsteps:\n  - goto: x\n"))
+	if err == nil || err.Error() != `1:8: video must be one of: off, on, retain-on-failure, got "always"` {
+		t.Errorf("Expected invalid video mode error, got %v", err)
+	}
+}
+
+func TestParseRetries(t *testing.T) {
+	scenario, err := ParseYAML(strings.NewReader("retries: 0\nsteps:\n  - goto: x\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if scenario.Retries == nil || *scenario.Retries != 0 {
+		t.Errorf("Expected retries 0 to be kept, got %v", scenario.Retries)
+	}
+
+	scenario, err = ParseYAML(strings.NewReader("steps:\n  - goto: x\n"))
+	if err != nil || scenario.Retries != nil {
+		t.Errorf("Expected no retries setting, got %v, %v", scenario, err)
+	}
+
+	_, err = ParseYAML(strings.NewReader("retries: -2\nsteps:\n  - goto: x\n"))
+	if err == nil || err.Error() != `1:10: retries must be a non-negative integer, got "-2"` {
+		t.Errorf("Expected invalid retries error, got %v", err)
+	}
+}
+
+func TestParseSoftAssertions(t *testing.T) {
+	yamlContent := `soft_assertions: true
+steps:
+  - assert:
+      type: exists
+      selector: "#banner"
+      soft: true
+`
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !scenario.SoftAssertions || !scenario.Steps[0].Soft {
+		t.Errorf("Expected soft assertions, got %+v", scenario)
+	}
+
+	_, err = ParseYAML(strings.NewReader("soft_assertions: yes please\nsteps:\n  - goto: x\n"))
+	if err == nil || err.Error() != `1:18: soft_assertions must be a boolean, got "yes please"` {
+		t.Errorf("Expected invalid soft_assertions error, got %v", err)
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	yamlContent := `
+desc: Variables test
+vars:
+  BASE_URL: "https://staging.example.com"
+  RETRIES: 3
+steps:
+  - goto: "${BASE_URL}/login"
+  - fill:
+      selector: "input[name='email']"
+      value: "${USER}@example.com"
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if scenario.Vars["BASE_URL"] != "https://staging.example.com" {
+		t.Errorf("Expected BASE_URL var, got '%s'", scenario.Vars["BASE_URL"])
+	}
+	if scenario.Vars["RETRIES"] != "3" {
+		t.Errorf("Expected RETRIES var '3', got '%s'", scenario.Vars["RETRIES"])
+	}
+
+	// References are kept as-is and expanded at execution time
+	if scenario.Steps[0].URL != "${BASE_URL}/login" {
+		t.Errorf("Expected unexpanded URL, got '%s'", scenario.Steps[0].URL)
+	}
+}
+
+func TestParseInvalidVars(t *testing.T) {
+	yamlContent := `
+desc: Variables test
+vars:
+  NESTED:
+    key: value
+steps:
+  - goto: "https://example.com"
+`
+
+	_, err := ParseYAML(strings.NewReader(yamlContent))
+	if err == nil {
+		t.Error("Expected error for non-scalar variable, got nil")
+	}
+}
+
+func TestParseStoreStep(t *testing.T) {
+	yamlContent := `
+desc: Store test
+steps:
+  - store:
+      name: order_id
+      from: text
+      selector: "#order-id"
+  - extract:
+      name: next_link
+      from: attribute
+      selector: "a.next"
+      attribute: href
+  - store:
+      name: row_count
+      from: eval
+      expression: "document.querySelectorAll('tr').length"
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	step := scenario.Steps[0]
+	if step.Type != "store" || step.Variable != "order_id" || step.Source != "text" || step.Selector != "#order-id" {
+		t.Errorf("Unexpected store step: %+v", step)
+	}
+
+	// extract is an alias for store
+	step = scenario.Steps[1]
+	if step.Type != "store" || step.Variable != "next_link" || step.Attribute != "href" {
+		t.Errorf("Unexpected extract step: %+v", step)
+	}
+
+	step = scenario.Steps[2]
+	if step.Source != "eval" || step.Expression != "document.querySelectorAll('tr').length" {
+		t.Errorf("Unexpected eval store step: %+v", step)
+	}
+}
+
+func TestParseStepPositions(t *testing.T) {
+	yamlContent := `desc: Position test
+steps:
+  - goto: "https://example.com"
+  - click:
+      selector: "#submit"
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if pos := scenario.Steps[0].Pos; pos.Line != 3 || pos.Column != 5 {
+		t.Errorf("Expected first step at 3:5, got %s", pos)
+	}
+	if pos := scenario.Steps[1].Pos; pos.Line != 4 || pos.Column != 5 {
+		t.Errorf("Expected second step at 4:5, got %s", pos)
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "invalid step format",
+			yaml:     "steps:\n  - goto: x\n  - just a string\n",
+			expected: "3:5: invalid step format",
+		},
+		{
+			name:     "no valid step type",
+			yaml:     "steps:\n  - goto: x\n  - bogus:\n      selector: a\n",
+			expected: "3:5: no valid step type found in step",
+		},
+		{
+			name:     "invalid timeout",
+			yaml:     "steps:\n  - goto: x\n    timeout: soon\n",
+			expected: "3:14: timeout must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected error starting with %q, got %q", tt.expected, err.Error())
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !parseErr.Pos.IsValid() {
+				t.Errorf("Expected ParseError with position, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "misspelled step field",
+			yaml:     "steps:\n  - click:\n      selecter: \"#submit\"\n",
+			expected: `3:7: unknown key "selecter" in click step (did you mean "selector"?)`,
+		},
+		{
+			name:     "misspelled step type",
+			yaml:     "steps:\n  - clik:\n      selector: \"#submit\"\n",
+			expected: `2:5: no valid step type found in step: unknown key "clik" (did you mean "click"?)`,
+		},
+		{
+			name:     "misspelled modifier",
+			yaml:     "steps:\n  - goto: x\n    timout: 100\n",
+			expected: `3:5: unknown key "timout" in goto step (did you mean "timeout"?)`,
+		},
+		{
+			name:     "misspelled scenario key",
+			yaml:     "desc: x\nstep:\n  - goto: x\n",
+			expected: `2:1: unknown key "step" in scenario (did you mean "steps"?)`,
+		},
+		{
+			name:     "missing required field",
+			yaml:     "steps:\n  - fill:\n      selector: \"#name\"\n",
+			expected: "3:7: fill step requires value",
+		},
+		{
+			name:     "wrong value type",
+			yaml:     "steps:\n  - assert:\n      type: url\n      contains: [a, b]\n",
+			expected: "4:17: contains must be a string, got a list",
+		},
+		{
+			name:     "wrong step shape",
+			yaml:     "steps:\n  - hover: \"#menu\"\n",
+			expected: "2:12: hover step must be a mapping with keys: selector, got \"#menu\"",
+		},
+		{
+			name:     "invalid enum value",
+			yaml:     "steps:\n  - store: {name: a, from: txt}\n",
+			expected: `2:28: invalid value "txt" for from, expected one of: text, value, attribute, url, eval (did you mean "text"?)`,
+		},
+		{
+			name:     "multiple actions",
+			yaml:     "steps:\n  - click: {selector: a}\n    fill: {selector: a, value: b}\n",
+			expected: "2:5: step has multiple actions: click, fill (use one action per step)",
+		},
+		{
+			name:     "unknown key in use step",
+			yaml:     "groups:\n  g:\n    steps:\n      - goto: x\nsteps:\n  - use: g\n    whith: {}\n",
+			expected: `7:5: unknown key "whith" in use step (did you mean "with"?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected error starting with %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseCollectsAllErrors(t *testing.T) {
+	yamlContent := `desc: Many problems
+steps:
+  - goto: "https://example.com"
+  - click:
+      selecter: "#a"
+  - fill:
+      selector: "#b"
+  - bogus: true
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if scenario == nil || len(scenario.Steps) != 1 || scenario.Steps[0].Type != "goto" {
+		t.Errorf("Expected the valid steps alongside the errors, got %+v", scenario)
+	}
+
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("Expected ErrorList, got %#v", err)
+	}
+
+	var lines []int
+	for _, parseErr := range list {
+		lines = append(lines, parseErr.Pos.Line)
+	}
+	expected := []int{5, 5, 7, 8}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("Expected errors on lines %v, got %v:\n%v", expected, lines, err)
+	}
+}
+
+func TestParseKeyboardSteps(t *testing.T) {
+	yamlContent := `desc: Keyboard test
+steps:
+  - press: Enter
+  - press:
+      key: "Control+K"
+      selector: "#search"
+  - type: "hello"
+  - type:
+      selector: "#search"
+      text: "ezpw"
+      delay: 50
+  - key_down: Shift
+  - key_up: Shift
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []types.Step{
+		{Type: "press", Key: "Enter"},
+		{Type: "press", Key: "Control+K", Selector: "#search"},
+		{Type: "type", Value: "hello"},
+		{Type: "type", Value: "ezpw", Selector: "#search", Delay: 50},
+		{Type: "key_down", Key: "Shift"},
+		{Type: "key_up", Key: "Shift"},
+	}
+	for i, want := range expected {
+		got := scenario.Steps[i]
+		if got.Type != want.Type || got.Key != want.Key || got.Selector != want.Selector ||
+			got.Value != want.Value || got.Delay != want.Delay {
+			t.Errorf("Step %d: expected %+v, got %+v", i+1, want, got)
+		}
+	}
+}
+
+func TestParseMouseSteps(t *testing.T) {
+	yamlContent := `desc: Mouse test
+steps:
+  - hover:
+      selector: "#menu"
+  - dblclick:
+      selector: "tr.row"
+  - right_click:
+      selector: "#file"
+  - click:
+      selector: "li.item"
+      button: middle
+      modifiers: [Shift, ControlOrMeta]
+      position: {x: 5, y: 7.5}
+  - click:
+      position: {x: 100, y: 200}
+  - drag:
+      from: "#card"
+      to: "#done"
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stepTypes := []string{"hover", "dblclick", "right_click", "click", "click", "drag"}
+	for i, stepType := range stepTypes {
+		if scenario.Steps[i].Type != stepType {
+			t.Errorf("Step %d: expected type %s, got %s", i+1, stepType, scenario.Steps[i].Type)
+		}
+	}
+
+	click := scenario.Steps[3]
+	if click.Button != "middle" || strings.Join(click.Modifiers, "+") != "Shift+ControlOrMeta" ||
+		click.Point == nil || click.Point.X != 5 || click.Point.Y != 7.5 {
+		t.Errorf("Unexpected click options: %+v", click)
+	}
+	if at := scenario.Steps[4]; at.Selector != "" || at.Point == nil || at.Point.X != 100 {
+		t.Errorf("Unexpected click at position: %+v", at)
+	}
+	if drag := scenario.Steps[5]; drag.Selector != "#card" || drag.Target != "#done" {
+		t.Errorf("Unexpected drag step: %+v", drag)
+	}
+}
+
+func TestParseMouseStepErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "click without target",
+			yaml:     "steps:\n  - click:\n      button: right\n",
+			expected: "3:7: click step requires selector or position",
+		},
+		{
+			name:     "invalid modifier",
+			yaml:     "steps:\n  - click:\n      selector: a\n      modifiers: [Shfit]\n",
+			expected: `4:19: invalid value "Shfit" for modifiers, expected one of: Alt, Control, ControlOrMeta, Meta, Shift (did you mean "Shift"?)`,
+		},
+		{
+			name:     "invalid position",
+			yaml:     "steps:\n  - click:\n      position: {x: left, y: 3}\n",
+			expected: "3:17: position must be a mapping with numeric x and y",
+		},
+		{
+			name:     "drag without target",
+			yaml:     "steps:\n  - drag:\n      from: \"#card\"\n",
+			expected: "3:7: drag step requires to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected error starting with %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseFormSteps(t *testing.T) {
+	yamlContent := `desc: Form test
+steps:
+  - select:
+      selector: "#country"
+      value: jp
+  - select:
+      selector: "#toppings"
+      label: [Cheese, Olives]
+  - select:
+      selector: "#size"
+      index: 2
+  - check:
+      selector: "#terms"
+  - uncheck:
+      selector: "#newsletter"
+  - upload:
+      selector: "input[type=file]"
+      files: fixtures/avatar.png
+  - assert:
+      type: selected
+      selector: "#toppings"
+      label: [Olives, Cheese]
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if step := scenario.Steps[0]; step.Type != "select" || strings.Join(step.OptionValues, ",") != "jp" {
+		t.Errorf("Unexpected select by value: %+v", step)
+	}
+	if step := scenario.Steps[1]; strings.Join(step.OptionLabels, ",") != "Cheese,Olives" {
+		t.Errorf("Unexpected select by labels: %+v", step)
+	}
+	if step := scenario.Steps[2]; len(step.OptionIndexes) != 1 || step.OptionIndexes[0] != 2 {
+		t.Errorf("Unexpected select by index: %+v", step)
+	}
+	if scenario.Steps[3].Type != "check" || scenario.Steps[4].Type != "uncheck" {
+		t.Errorf("Unexpected check steps: %s, %s", scenario.Steps[3].Type, scenario.Steps[4].Type)
+	}
+	if step := scenario.Steps[5]; step.Type != "upload" || strings.Join(step.Files, ",") != "fixtures/avatar.png" {
+		t.Errorf("Unexpected upload step: %+v", step)
+	}
+	if step := scenario.Steps[6]; step.AssertType != "selected" || len(step.OptionLabels) != 2 {
+		t.Errorf("Unexpected selected assertion: %+v", step)
+	}
+}
+
+func TestParseSelectRequiresOneOption(t *testing.T) {
+	yamlContent := "steps:\n  - select:\n      selector: \"#size\"\n      value: m\n      index: 1\n"
+
+	_, err := ParseYAML(strings.NewReader(yamlContent))
+	expected := "3:7: select step requires exactly one of: value, label, index"
+	if err == nil || !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("Expected error starting with %q, got %v", expected, err)
+	}
+}
+
+func TestParseWaitSteps(t *testing.T) {
+	yamlContent := `desc: Wait test
+steps:
+  - wait: 500
+  - wait:
+      selector: "#spinner"
+      state: hidden
+  - wait:
+      url: "**/dashboard"
+  - wait:
+      load_state: networkidle
+  - wait:
+      function: "() => window.ready"
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []types.Step{
+		{Type: "wait", Sleep: 500},
+		{Type: "wait", Selector: "#spinner", State: "hidden"},
+		{Type: "wait", URL: "**/dashboard"},
+		{Type: "wait", LoadState: "networkidle"},
+		{Type: "wait", Expression: "() => window.ready"},
+	}
+	for i, want := range expected {
+		got := scenario.Steps[i]
+		if got.Type != want.Type || got.Sleep != want.Sleep || got.Selector != want.Selector || got.State != want.State ||
+			got.URL != want.URL || got.LoadState != want.LoadState || got.Expression != want.Expression {
+			t.Errorf("Step %d: expected %+v, got %+v", i+1, want, got)
+		}
+	}
+}
+
+func TestParseWaitStepErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "two conditions",
+			yaml:     "steps:\n  - wait:\n      selector: \"#a\"\n      url: \"**/b\"\n",
+			expected: "3:7: wait step requires exactly one of: selector, url, load_state, function, sleep",
+		},
+		{
+			name:     "unknown load state",
+			yaml:     "steps:\n  - wait:\n      load_state: idle\n",
+			expected: "3:19: invalid value \"idle\" for load_state, expected one of: load, domcontentloaded, networkidle",
+		},
+		{
+			name:     "negative sleep",
+			yaml:     "steps:\n  - wait: -1\n",
+			expected: "2:11: invalid value for sleep: sleep must be at least 1, got -1",
+		},
+		{
+			name:     "zero sleep",
+			yaml:     "steps:\n  - wait:\n      sleep: 0\n",
+			expected: "3:14: invalid value for sleep: sleep must be at least 1, got 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseScreenshotSteps(t *testing.T) {
+	yamlContent := `desc: Screenshot test
+steps:
+  - screenshot: home.png
+  - screenshot:
+      full_page: true
+  - screenshot:
+      selector: "#chart"
+      path: charts/sales.png
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []types.Step{
+		{Type: "screenshot", Path: "home.png"},
+		{Type: "screenshot", FullPage: true},
+		{Type: "screenshot", Selector: "#chart", Path: "charts/sales.png"},
+	}
+	for i, want := range expected {
+		got := scenario.Steps[i]
+		if got.Type != want.Type || got.Path != want.Path || got.FullPage != want.FullPage || got.Selector != want.Selector {
+			t.Errorf("Step %d: expected %+v, got %+v", i+1, want, got)
+		}
+	}
+
+	_, err = ParseYAML(strings.NewReader("steps:\n  - screenshot:\n      full_page: yes please\n"))
+	if err == nil || err.Error() != `3:18: full_page must be a boolean, got "yes please"` {
+		t.Errorf("Expected boolean error, got %v", err)
+	}
+}
+
+func TestParseRetryUntil(t *testing.T) {
+	yamlContent := `steps:
+  - retry_until:
+      steps:
+        - click:
+            selector: "#refresh"
+        - assert:
+            type: text_content
+            selector: "#status"
+            contains: done
+      max_attempts: 5
+      interval: 0
+    timeout: 20000
+`
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	step := scenario.Steps[0]
+	if step.Type != "retry_until" || step.MaxAttempts != 5 || step.Timeout != 20000 {
+		t.Errorf("Unexpected retry_until step: %+v", step)
+	}
+	if step.Interval == nil || *step.Interval != 0 {
+		t.Errorf("Expected interval 0 to be kept, got %v", step.Interval)
+	}
+	if len(step.Steps) != 2 || step.Steps[0].Type != "click" || step.Steps[1].Contains != "done" {
+		t.Fatalf("Expected nested click and assert steps, got %+v", step.Steps)
+	}
+	if step.Steps[1].Pos.Line != 6 {
+		t.Errorf("Expected nested step at line 6, got %v", step.Steps[1].Pos)
+	}
+}
+
+func TestParseRetryUntilErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "missing steps",
+			yaml:     "steps:\n  - retry_until:\n      max_attempts: 2\n",
+			expected: "3:7: retry_until step requires steps",
+		},
+		{
+			name:     "zero attempts",
+			yaml:     "steps:\n  - retry_until:\n      steps:\n        - goto: x\n      max_attempts: 0\n",
+			expected: "5:21: invalid value for max_attempts: max_attempts must be at least 1, got 0",
+		},
+		{
+			name:     "invalid nested step",
+			yaml:     "steps:\n  - retry_until:\n      steps:\n        - clik: x\n",
+			expected: `4:11: no valid step type found in step: unknown key "clik"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	yamlContent := `steps:
+  - click:
+      selector: "#accept"
+    if:
+      exists: "#cookie-banner"
+  - goto: /setup
+    unless:
+      var: SKIP_SETUP
+  - if:
+      var: ENV
+      equals: production
+    then:
+      - goto: /live
+    else:
+      - goto: /sandbox
+      - fill:
+          selector: "#name"
+          value: test
+    timeout: 10000
+`
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if click := scenario.Steps[0]; click.Type != "click" || click.If == nil || click.If.Exists != "#cookie-banner" {
+		t.Errorf("Expected click with an exists condition, got %+v", click)
+	}
+	if setup := scenario.Steps[1]; setup.Unless == nil || setup.Unless.Variable != "SKIP_SETUP" || setup.If != nil {
+		t.Errorf("Expected goto with an unless condition, got %+v", setup)
+	}
+
+	block := scenario.Steps[2]
+	if block.Type != "if" || block.Timeout != 10000 || block.If == nil || block.If.Equals == nil || *block.If.Equals != "production" {
+		t.Errorf("Unexpected if block: %+v", block)
+	}
+	if len(block.Steps) != 1 || block.Steps[0].URL != "/live" {
+		t.Errorf("Expected one then step, got %+v", block.Steps)
+	}
+	if len(block.Else) != 2 || block.Else[1].Type != "fill" || block.Else[1].Pos.Line != 16 {
+		t.Errorf("Expected two else steps, got %+v", block.Else)
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{
+			name:     "condition not a mapping",
+			yaml:     "steps:\n  - goto: x\n    if: true\n",
+			expected: "3:9: if must be a mapping with one of: exists, visible, url, url_matches, var, got \"true\"",
+		},
+		{
+			name:     "unknown condition key",
+			yaml:     "steps:\n  - goto: x\n    if:\n      exist: \"#a\"\n",
+			expected: `4:7: invalid value for if: unknown key "exist" in condition (did you mean "exists"?)`,
+		},
+		{
+			name:     "block without condition",
+			yaml:     "steps:\n  - then:\n      - goto: x\n",
+			expected: "2:5: if block requires exactly one of: if, unless",
+		},
+		{
+			name:     "unknown block key",
+			yaml:     "steps:\n  - if:\n      var: X\n    then:\n      - goto: x\n    otherwise:\n      - goto: y\n",
+			expected: `6:5: unknown key "otherwise" in if block`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.yaml))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseAssertions(t *testing.T) {
+	yamlContent := `desc: Assertion test
+steps:
+  - assert:
+      type: count
+      selector: "li"
+      min: 1
+      max: 3
+  - assert:
+      type: count
+      selector: "li.done"
+      equals: 0
+  - assert:
+      type: attribute
+      selector: "a#next"
+      name: href
+      value: "/page/2"
+  - assert:
+      type: css
+      selector: "#alert"
+      property: color
+      value: "rgb(255, 0, 0)"
+  - assert:
+      type: visible
+      selector: "#toast"
+      not: true
+  - assert:
+      type: text_content
+      selector: "h1"
+      matches: "^Order #\\d+$"
+      normalize_whitespace: true
+      ignore_case: true
+`
+
+	scenario, err := ParseYAML(strings.NewReader(yamlContent))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	count := scenario.Steps[0]
+	if count.Min == nil || *count.Min != 1 || count.Max == nil || *count.Max != 3 || count.Equals != "" {
+		t.Errorf("Expected count between 1 and 3, got %+v", count)
+	}
+	if none := scenario.Steps[1]; none.Equals != "0" || none.Min != nil {
+		t.Errorf("Expected count equals 0, got %+v", none)
+	}
+	if attribute := scenario.Steps[2]; attribute.Attribute != "href" || attribute.Value != "/page/2" {
+		t.Errorf("Expected href attribute '/page/2', got %+v", attribute)
+	}
+	if css := scenario.Steps[3]; css.Property != "color" || css.Value != "rgb(255, 0, 0)" {
+		t.Errorf("Expected color 'rgb(255, 0, 0)', got %+v", css)
+	}
+	if negated := scenario.Steps[4]; !negated.Not {
+		t.Errorf("Expected negated assertion, got %+v", negated)
+	}
+	if text := scenario.Steps[5]; text.Matches != `^Order #\d+$` || !text.NormalizeWhitespace || !text.IgnoreCase {
+		t.Errorf("Expected case-insensitive, whitespace-normalized pattern, got %+v", text)
+	}
+}
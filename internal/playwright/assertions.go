@@ -1,81 +1,425 @@
 package playwright
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/haruotsu/ezpw/internal/browser"
 )
 
-// Assertion provides assertion methods for a page
+// DefaultAssertionTimeout is how long assertions retry by default, in milliseconds
+const DefaultAssertionTimeout = 5000
+
+// pollIntervals are the pauses between checks of a retrying assertion; the
+// last one repeats until the timeout
+var pollIntervals = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Assertion provides assertion methods for a page. Each assertion checks the
+// page repeatedly until it passes or the timeout elapses, so it tolerates
+// content that is still loading or changing.
 type Assertion struct {
-	page browser.Page
+	page    browser.Page
+	negate  bool
+	timeout int
 }
 
-// NewAssertion creates a new assertion instance
+// NewAssertion creates a new assertion instance that retries for up to
+// DefaultAssertionTimeout
 func NewAssertion(page browser.Page) *Assertion {
-	return &Assertion{page: page}
+	return &Assertion{page: page, timeout: DefaultAssertionTimeout}
 }
 
-// AssertTextContent asserts that an element contains the expected text content
-func (a *Assertion) AssertTextContent(selector, expectedText string) error {
-	// Check if element exists first
-	exists, err := a.page.ElementExists(selector)
-	if err != nil {
-		return fmt.Errorf("failed to check element existence for selector %s: %w", selector, err)
-	}
+// Not returns assertions that pass when the expected condition does not hold
+func (a *Assertion) Not() *Assertion {
+	negated := *a
+	negated.negate = !a.negate
+	return &negated
+}
+
+// WithTimeout returns assertions that retry for up to timeout milliseconds.
+// Zero or less checks once.
+func (a *Assertion) WithTimeout(timeout int) *Assertion {
+	limited := *a
+	limited.timeout = timeout
+	return &limited
+}
 
-	if !exists {
-		return fmt.Errorf("element with selector %s not found", selector)
+// condition is a state of the page expected by an assertion
+type condition struct {
+	// subject and expectation read as "expected <subject> <expectation>",
+	// e.g. "element #terms" and "to be checked"
+	subject     string
+	expectation string
+	// selector of the element the check reads, if any
+	selector string
+	// check reads the current state once, reporting whether it matches and
+	// what was observed
+	check func() (bool, string, error)
+}
+
+// expect checks a condition, or its opposite for negated assertions, until it
+// holds or the timeout elapses. The error reports what the last check
+// observed. A missing element matches no condition, so a negated assertion
+// about it passes.
+func (a *Assertion) expect(c condition) error {
+	deadline := time.Now().Add(time.Duration(a.timeout) * time.Millisecond)
+	for attempt := 0; ; attempt++ {
+		matched, actual, err := a.observe(c)
+		if err == nil && matched != a.negate {
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("expected %s %s, got %s", c.subject, a.phrase(c.expectation), actual)
+		}
+		time.Sleep(min(pollIntervals[min(attempt, len(pollIntervals)-1)], remaining))
 	}
+}
 
-	// Get text content
-	actualText, err := a.page.GetElementText(selector)
-	if err != nil {
-		return fmt.Errorf("failed to get text content for selector %s: %w", selector, err)
+// observe checks a condition once
+func (a *Assertion) observe(c condition) (bool, string, error) {
+	if c.selector != "" {
+		exists, err := a.page.ElementExists(c.selector)
+		if err != nil {
+			return false, "", err
+		}
+		if !exists {
+			return false, "no matching element", nil
+		}
 	}
+	return c.check()
+}
 
-	if actualText != expectedText {
-		return fmt.Errorf("text content mismatch for selector %s: expected '%s', got '%s'",
-			selector, expectedText, actualText)
+// phrase negates an expectation such as "to be checked" for negated assertions
+func (a *Assertion) phrase(expectation string) string {
+	if a.negate {
+		return "not " + expectation
 	}
+	return expectation
+}
+
+// AssertTextContent asserts that the text content of an element contains the
+// expected text
+func (a *Assertion) AssertTextContent(selector, expectedText string) error {
+	return a.AssertText(selector, TextMatch{Mode: MatchContains, Expected: expectedText})
+}
 
-	return nil
+// AssertText asserts that the text content of an element matches
+func (a *Assertion) AssertText(selector string, match TextMatch) error {
+	return a.expect(condition{
+		subject:     "text of element " + selector,
+		expectation: match.String(),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actualText, err := a.page.GetElementText(selector)
+			if err != nil {
+				return false, "", fmt.Errorf("failed to get text content for selector %s: %w", selector, err)
+			}
+			matched, err := match.Match(actualText)
+			return matched, quote(actualText), err
+		},
+	})
 }
 
 // AssertURLContains asserts that the current URL contains the expected substring
 func (a *Assertion) AssertURLContains(expectedSubstring string) error {
-	currentURL := a.page.URL()
-
-	if !strings.Contains(currentURL, expectedSubstring) {
-		return fmt.Errorf("URL does not contain expected substring: expected URL to contain '%s', got '%s'",
-			expectedSubstring, currentURL)
-	}
-
-	return nil
+	return a.AssertURLMatches(TextMatch{Mode: MatchContains, Expected: expectedSubstring})
 }
 
 // AssertURL asserts that the current URL matches the expected URL exactly
 func (a *Assertion) AssertURL(expectedURL string) error {
-	currentURL := a.page.URL()
+	return a.AssertURLMatches(TextMatch{Mode: MatchEquals, Expected: expectedURL})
+}
 
-	if currentURL != expectedURL {
-		return fmt.Errorf("URL mismatch: expected '%s', got '%s'", expectedURL, currentURL)
-	}
+// AssertURLMatches asserts that the current URL matches
+func (a *Assertion) AssertURLMatches(match TextMatch) error {
+	return a.expect(condition{
+		subject:     "URL",
+		expectation: match.String(),
+		check: func() (bool, string, error) {
+			currentURL := a.page.URL()
+			matched, err := match.Match(currentURL)
+			return matched, quote(currentURL), err
+		},
+	})
+}
 
-	return nil
+// AssertTitle asserts that the page title matches
+func (a *Assertion) AssertTitle(match TextMatch) error {
+	return a.expect(condition{
+		subject:     "page title",
+		expectation: match.String(),
+		check: func() (bool, string, error) {
+			title, err := a.page.Title()
+			if err != nil {
+				return false, "", err
+			}
+			matched, err := match.Match(title)
+			return matched, quote(title), err
+		},
+	})
 }
 
 // AssertExists asserts that an element with the given selector exists
 func (a *Assertion) AssertExists(selector string) error {
-	exists, err := a.page.ElementExists(selector)
-	if err != nil {
-		return fmt.Errorf("failed to check element existence for selector %s: %w", selector, err)
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: "to exist",
+		check: func() (bool, string, error) {
+			count, err := a.page.GetElementCount(selector)
+			if err != nil {
+				return false, "", err
+			}
+			return count > 0, fmt.Sprintf("%d matching elements", count), nil
+		},
+	})
+}
+
+// AssertVisible asserts that an element is visible
+func (a *Assertion) AssertVisible(selector string) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: "to be visible",
+		selector:    selector,
+		check: func() (bool, string, error) {
+			visible, err := a.page.IsVisible(selector)
+			if err != nil {
+				return false, "", err
+			}
+			if visible {
+				return true, "visible", nil
+			}
+			return false, "hidden", nil
+		},
+	})
+}
+
+// AssertEnabled asserts that an element is enabled
+func (a *Assertion) AssertEnabled(selector string) error {
+	return a.expectEnabled(selector, true)
+}
+
+// AssertDisabled asserts that an element exists and is disabled. Unlike a
+// negated AssertEnabled, it fails when no element matches.
+func (a *Assertion) AssertDisabled(selector string) error {
+	return a.expectEnabled(selector, false)
+}
+
+// expectEnabled checks that an element is enabled, or disabled when enabled
+// is false
+func (a *Assertion) expectEnabled(selector string, enabled bool) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: "to be " + enabledState(enabled),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actual, err := a.page.IsEnabled(selector)
+			if err != nil {
+				return false, "", err
+			}
+			return actual == enabled, enabledState(actual), nil
+		},
+	})
+}
+
+// AssertChecked asserts that a checkbox or radio button is checked
+func (a *Assertion) AssertChecked(selector string) error {
+	return a.expectChecked(selector, true)
+}
+
+// AssertUnchecked asserts that a checkbox or radio button exists and is not
+// checked. Unlike a negated AssertChecked, it fails when no element matches.
+func (a *Assertion) AssertUnchecked(selector string) error {
+	return a.expectChecked(selector, false)
+}
+
+// expectChecked checks that an element is checked, or unchecked when checked
+// is false
+func (a *Assertion) expectChecked(selector string, checked bool) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: "to be " + checkedState(checked),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actual, err := a.page.IsChecked(selector)
+			if err != nil {
+				return false, "", err
+			}
+			return actual == checked, checkedState(actual), nil
+		},
+	})
+}
+
+// AssertSelected asserts that the selected options of a <select> element are
+// exactly the given values, or labels when values is empty, in any order
+func (a *Assertion) AssertSelected(selector string, values, labels []string) error {
+	expected, kind := values, "values"
+	if len(values) == 0 {
+		expected, kind = labels, "labels"
 	}
 
-	if !exists {
-		return fmt.Errorf("element with selector %s does not exist", selector)
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: fmt.Sprintf("to have selected %s %s", kind, quoteList(expected)),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			options, err := a.page.GetSelectedOptions(selector)
+			if err != nil {
+				return false, "", err
+			}
+			actual := make([]string, len(options))
+			for i, option := range options {
+				actual[i] = option.Value
+				if kind == "labels" {
+					actual[i] = option.Label
+				}
+			}
+			return sameStrings(expected, actual), quoteList(actual), nil
+		},
+	})
+}
+
+// AssertCount asserts the number of elements matching selector: exactly
+// equals when given, and at least min and at most max when given
+func (a *Assertion) AssertCount(selector string, equals, minimum, maximum *int) error {
+	if equals == nil && minimum == nil && maximum == nil {
+		return errors.New("count assertion requires equals, min or max")
 	}
 
-	return nil
+	return a.expect(condition{
+		subject:     "elements matching " + selector,
+		expectation: countExpectation(equals, minimum, maximum),
+		check: func() (bool, string, error) {
+			count, err := a.page.GetElementCount(selector)
+			if err != nil {
+				return false, "", err
+			}
+			matched := (equals == nil || count == *equals) &&
+				(minimum == nil || count >= *minimum) &&
+				(maximum == nil || count <= *maximum)
+			return matched, fmt.Sprintf("%d", count), nil
+		},
+	})
+}
+
+// countExpectation describes the expected count, e.g. "to number at least 2"
+func countExpectation(equals, minimum, maximum *int) string {
+	var parts []string
+	if equals != nil {
+		parts = append(parts, fmt.Sprintf("exactly %d", *equals))
+	}
+	if minimum != nil {
+		parts = append(parts, fmt.Sprintf("at least %d", *minimum))
+	}
+	if maximum != nil {
+		parts = append(parts, fmt.Sprintf("at most %d", *maximum))
+	}
+	return "to number " + strings.Join(parts, " and ")
+}
+
+// AssertAttribute asserts that an attribute of an element has the expected value
+func (a *Assertion) AssertAttribute(selector, name, expected string) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: fmt.Sprintf("to have attribute %s='%s'", name, expected),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actual, err := a.page.GetElementAttribute(selector, name)
+			if err != nil {
+				return false, "", err
+			}
+			return actual == expected, quote(actual), nil
+		},
+	})
+}
+
+// AssertValue asserts that an input, textarea or select element has the
+// expected value
+func (a *Assertion) AssertValue(selector, expected string) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: fmt.Sprintf("to have value '%s'", expected),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actual, err := a.page.GetElementValue(selector)
+			if err != nil {
+				return false, "", err
+			}
+			return actual == expected, quote(actual), nil
+		},
+	})
+}
+
+// AssertCSS asserts that the computed value of a CSS property of an element
+// is the expected value, such as "rgb(255, 0, 0)" for color
+func (a *Assertion) AssertCSS(selector, property, expected string) error {
+	return a.expect(condition{
+		subject:     "element " + selector,
+		expectation: fmt.Sprintf("to have CSS %s '%s'", property, expected),
+		selector:    selector,
+		check: func() (bool, string, error) {
+			actual, err := a.page.GetCSSProperty(selector, property)
+			if err != nil {
+				return false, "", err
+			}
+			return actual == expected, quote(actual), nil
+		},
+	})
+}
+
+func enabledState(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func checkedState(checked bool) string {
+	if checked {
+		return "checked"
+	}
+	return "unchecked"
+}
+
+// sameStrings reports whether a and b hold the same strings in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// quote formats an observed value as 'value' for error messages
+func quote(value string) string {
+	return "'" + value + "'"
+}
+
+// quoteList formats strings as ['a', 'b'] for error messages
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
 }
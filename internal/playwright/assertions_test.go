@@ -1,8 +1,11 @@
 package playwright
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/haruotsu/ezpw/internal/browser"
 	"github.com/haruotsu/ezpw/pkg/types"
 )
 
@@ -53,6 +56,63 @@ func TestAssertTextContent(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for non-matching text content, got nil")
 	}
+
+	// A longer expected text is not contained in a shorter one
+	err = assertion.AssertTextContent("#title", "Welcome back")
+	if err == nil {
+		t.Error("Expected error for expected text longer than the actual text, got nil")
+	}
+}
+
+func TestAssertTextMatchModes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  3000,
+	}
+
+	browser, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating browser, got %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.NewPage()
+	if err != nil {
+		t.Fatalf("Expected no error creating page, got %v", err)
+	}
+
+	html := `<html><body><h1 id="order">
+		Order   #42
+	</h1></body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Expected no error setting content, got %v", err)
+	}
+
+	assertion := NewAssertion(page).WithTimeout(1000)
+	passing := map[string]TextMatch{
+		"equals normalized":         {Mode: MatchEquals, Expected: "Order #42", NormalizeWhitespace: true},
+		"matches":                   {Mode: MatchRegexp, Expected: `Order\s+#\d+`},
+		"starts with ignoring case": {Mode: MatchStartsWith, Expected: "order", NormalizeWhitespace: true, IgnoreCase: true},
+		"ends with":                 {Mode: MatchEndsWith, Expected: "#42", NormalizeWhitespace: true},
+	}
+	for name, match := range passing {
+		if err := assertion.AssertText("#order", match); err != nil {
+			t.Errorf("Expected %s to pass, got %v", name, err)
+		}
+	}
+
+	err = assertion.AssertText("#order", TextMatch{Mode: MatchEquals, Expected: "Order #42"})
+	if err == nil {
+		t.Fatal("Expected error comparing text without normalizing whitespace, got nil")
+	}
+	if !strings.Contains(err.Error(), "expected text of element #order to equal 'Order #42', got") {
+		t.Errorf("Expected message with the expectation and actual text, got %v", err)
+	}
 }
 
 func TestAssertURL(t *testing.T) {
@@ -158,3 +218,229 @@ func TestAssertExists(t *testing.T) {
 		t.Error("Expected error for non-existing element, got nil")
 	}
 }
+
+func TestAssertElementStates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  1000,
+	}
+
+	browser, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating browser, got %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.NewPage()
+	if err != nil {
+		t.Fatalf("Expected no error creating page, got %v", err)
+	}
+
+	html := `<html><head><title>Inbox (3)</title></head><body>
+		<ul><li>a</li><li>b</li><li class="done">c</li></ul>
+		<a id="next" href="/page/2">Next</a>
+		<input id="email" value="user@example.com">
+		<button id="send" disabled>Send</button>
+		<input id="terms" type="checkbox">
+		<p id="alert" style="color: red">Error</p>
+		<div id="toast" style="display: none">Saved</div>
+	</body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Expected no error setting content, got %v", err)
+	}
+
+	assertion := NewAssertion(page).WithTimeout(1000)
+	one, two, three := 1, 2, 3
+
+	passing := map[string]error{
+		"not exists":      assertion.Not().AssertExists("#missing"),
+		"visible":         assertion.AssertVisible("#alert"),
+		"hidden":          assertion.Not().AssertVisible("#toast"),
+		"disabled":        assertion.AssertDisabled("#send"),
+		"unchecked":       assertion.AssertUnchecked("#terms"),
+		"hidden missing":  assertion.Not().AssertVisible("#missing"),
+		"not enabled":     assertion.Not().AssertEnabled("#missing"),
+		"enabled":         assertion.AssertEnabled("#email"),
+		"count equals":    assertion.AssertCount("li", &three, nil, nil),
+		"count range":     assertion.AssertCount("li.done", nil, &one, &two),
+		"attribute":       assertion.AssertAttribute("#next", "href", "/page/2"),
+		"value":           assertion.AssertValue("#email", "user@example.com"),
+		"title":           assertion.AssertTitle(TextMatch{Mode: MatchEquals, Expected: "Inbox (3)"}),
+		"title contains":  assertion.AssertTitle(TextMatch{Expected: "Inbox"}),
+		"css":             assertion.AssertCSS("#alert", "color", "rgb(255, 0, 0)"),
+		"not text":        assertion.Not().AssertTextContent("#alert", "OK"),
+		"not missing css": assertion.Not().AssertCSS("#missing", "color", "red"),
+	}
+	for name, err := range passing {
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", name, err)
+		}
+	}
+
+	failing := map[string]error{
+		"exists":       assertion.Not().AssertExists("#email"),
+		"visible":      assertion.AssertVisible("#toast"),
+		"enabled":      assertion.AssertEnabled("#send"),
+		"count":        assertion.AssertCount("li", nil, nil, &two),
+		"attribute":    assertion.AssertAttribute("#next", "href", "/page/3"),
+		"value":        assertion.Not().AssertValue("#email", "user@example.com"),
+		"title":        assertion.AssertTitle(TextMatch{Mode: MatchEquals, Expected: "Inbox"}),
+		"not css":      assertion.Not().AssertCSS("#alert", "color", "rgb(255, 0, 0)"),
+		"missing text": assertion.AssertTextContent("#missing", "x"),
+		"disabled":     assertion.AssertDisabled("#email"),
+		"unchecked":    assertion.Not().AssertUnchecked("#terms"),
+		// A selector that matches nothing must not pass as disabled or unchecked
+		"missing disabled":  assertion.AssertDisabled("#missing"),
+		"missing unchecked": assertion.AssertUnchecked("#missing"),
+	}
+	for name, err := range failing {
+		if err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+
+	err = assertion.AssertCount("li", nil, nil, &two)
+	if err == nil || err.Error() != "expected elements matching li to number at most 2, got 3" {
+		t.Errorf("Expected count mismatch message, got %v", err)
+	}
+}
+
+func TestAssertionsRetry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  3000,
+	}
+
+	browser, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Expected no error creating browser, got %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.NewPage()
+	if err != nil {
+		t.Fatalf("Expected no error creating page, got %v", err)
+	}
+
+	html := `<html><body><p id="status">Saving...</p><div id="spinner">...</div><script>
+		setTimeout(() => {
+			document.getElementById('status').textContent = 'Saved';
+			document.getElementById('spinner').remove();
+			document.body.insertAdjacentHTML('beforeend', '<a id="undo">Undo</a>');
+		}, 500);
+	</script></body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Expected no error setting content, got %v", err)
+	}
+
+	assertion := NewAssertion(page).WithTimeout(3000)
+	if err := assertion.AssertTextContent("#status", "Saved"); err != nil {
+		t.Errorf("Expected text to change to 'Saved', got %v", err)
+	}
+	if err := assertion.Not().AssertExists("#spinner"); err != nil {
+		t.Errorf("Expected spinner to be removed, got %v", err)
+	}
+	if err := assertion.AssertVisible("#undo"); err != nil {
+		t.Errorf("Expected undo link to appear, got %v", err)
+	}
+
+	start := time.Now()
+	err = assertion.WithTimeout(500).AssertTextContent("#status", "Failed")
+	if err == nil {
+		t.Fatal("Expected error for text that never appears, got nil")
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("Expected assertion to retry for the timeout, returned after %v", elapsed)
+	}
+	if !strings.Contains(err.Error(), "got 'Saved'") {
+		t.Errorf("Expected error to report the last observed text, got %v", err)
+	}
+}
+
+// changingTextPage is a page whose element text changes on every read
+type changingTextPage struct {
+	browser.Page
+	texts []string
+	reads int
+}
+
+func (p *changingTextPage) ElementExists(string) (bool, error) {
+	return true, nil
+}
+
+func (p *changingTextPage) GetElementText(string) (string, error) {
+	text := p.texts[min(p.reads, len(p.texts)-1)]
+	p.reads++
+	return text, nil
+}
+
+func TestAssertionPolling(t *testing.T) {
+	page := &changingTextPage{texts: []string{"Loading", "Loading", "Ready"}}
+	if err := NewAssertion(page).AssertTextContent("#status", "Ready"); err != nil {
+		t.Errorf("Expected assertion to pass once the text changes, got %v", err)
+	}
+	if page.reads != 3 {
+		t.Errorf("Expected 3 reads, got %d", page.reads)
+	}
+
+	page = &changingTextPage{texts: []string{"Loading", "Ready"}}
+	err := NewAssertion(page).WithTimeout(0).AssertTextContent("#status", "Ready")
+	if err == nil || err.Error() != "expected text of element #status to contain 'Ready', got 'Loading'" {
+		t.Errorf("Expected a single failed check, got %v", err)
+	}
+
+	page = &changingTextPage{texts: []string{"Loading", "Failed"}}
+	err = NewAssertion(page).WithTimeout(200).AssertTextContent("#status", "Ready")
+	if err == nil || !strings.HasSuffix(err.Error(), "got 'Failed'") {
+		t.Errorf("Expected error to report the last observed text, got %v", err)
+	}
+}
+
+// missingElementPage is a page on which no selector matches
+type missingElementPage struct {
+	browser.Page
+}
+
+func (missingElementPage) ElementExists(string) (bool, error) {
+	return false, nil
+}
+
+func (missingElementPage) GetElementCount(string) (int, error) {
+	return 0, nil
+}
+
+func TestAssertMissingElement(t *testing.T) {
+	assertion := NewAssertion(missingElementPage{}).WithTimeout(0)
+
+	passing := map[string]error{
+		"not_exists":  assertion.Not().AssertExists("#typo"),
+		"hidden":      assertion.Not().AssertVisible("#typo"),
+		"not enabled": assertion.Not().AssertEnabled("#typo"),
+		"not checked": assertion.Not().AssertChecked("#typo"),
+	}
+	for name, err := range passing {
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", name, err)
+		}
+	}
+
+	failing := map[string]error{
+		"disabled":  assertion.AssertDisabled("#typo"),
+		"unchecked": assertion.AssertUnchecked("#typo"),
+	}
+	for name, err := range failing {
+		if err == nil || err.Error() != "expected element #typo to be "+name+", got no matching element" {
+			t.Errorf("%s: expected missing element error, got %v", name, err)
+		}
+	}
+}
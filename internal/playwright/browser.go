@@ -3,6 +3,8 @@ package playwright
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/haruotsu/ezpw/internal/browser"
 	"github.com/haruotsu/ezpw/internal/errors"
@@ -12,14 +14,20 @@ import (
 
 // playwrightBrowser implements browser.Browser interface using Playwright
 type playwrightBrowser struct {
-	pw      *playwright.Playwright
-	browser playwright.Browser
-	config  types.Config
+	pw       *playwright.Playwright
+	browser  playwright.Browser
+	config   types.Config
+	mu       sync.Mutex
+	videoDir string // Scratch directory for videos being recorded
 }
 
 // playwrightPage implements browser.Page interface using Playwright
 type playwrightPage struct {
-	page playwright.Page
+	page      playwright.Page
+	context   playwright.BrowserContext
+	video     playwright.Video
+	videoPath string
+	tracing   bool
 }
 
 // NewBrowser creates a new browser instance that implements browser.Browser interface
@@ -73,18 +81,76 @@ func NewBrowser(config types.Config) (browser.Browser, error) {
 	}, nil
 }
 
-// NewPage creates a new page
+// NewPage creates a new page in a fresh browser context so that pages
+// created concurrently do not share cookies, storage or cache
 func (b *playwrightBrowser) NewPage() (browser.Page, error) {
-	page, err := b.browser.NewPage()
+	return b.NewPageWith(browser.PageOptions{})
+}
+
+// NewPageWith creates a new page in a fresh browser context configured by options
+func (b *playwrightBrowser) NewPageWith(options browser.PageOptions) (browser.Page, error) {
+	var contextOptions playwright.BrowserNewContextOptions
+	if options.RecordVideo {
+		dir, err := b.scratchVideoDir()
+		if err != nil {
+			return nil, err
+		}
+		contextOptions.RecordVideo = &playwright.RecordVideo{Dir: dir}
+	}
+
+	context, err := b.browser.NewContext(contextOptions)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create browser context: %w", err)
+	}
+
+	tracing := b.config.Trace != "" && b.config.Trace != types.RecordOff
+	if tracing {
+		err := context.Tracing().Start(playwright.TracingStartOptions{
+			Screenshots: playwright.Bool(true),
+			Snapshots:   playwright.Bool(true),
+			Sources:     playwright.Bool(true),
+		})
+		if err != nil {
+			_ = context.Close()
+			return nil, fmt.Errorf("failed to start tracing: %w", err)
+		}
+	}
+
+	page, err := context.NewPage()
+	if err != nil {
+		_ = context.Close()
 		return nil, fmt.Errorf("failed to create new page: %w", err)
 	}
 
-	return &playwrightPage{page: page}, nil
+	p := &playwrightPage{page: page, context: context, tracing: tracing}
+	if options.RecordVideo {
+		p.video = page.Video()
+	}
+	p.SetTimeout(b.config.Timeout)
+	return p, nil
+}
+
+// scratchVideoDir returns the directory Playwright records videos into,
+// creating it on first use. Kept videos are moved out by Page.Close.
+func (b *playwrightBrowser) scratchVideoDir() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.videoDir == "" {
+		dir, err := os.MkdirTemp("", "ezpw-videos-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create video directory: %w", err)
+		}
+		b.videoDir = dir
+	}
+	return b.videoDir, nil
 }
 
 // Close closes the browser and cleans up resources
 func (b *playwrightBrowser) Close() error {
+	if b.videoDir != "" {
+		defer os.RemoveAll(b.videoDir)
+	}
 	if b.browser != nil {
 		if err := b.browser.Close(); err != nil {
 			return fmt.Errorf("failed to close browser: %w", err)
@@ -98,6 +164,50 @@ func (b *playwrightBrowser) Close() error {
 	return nil
 }
 
+// Close closes the page and its browser context
+func (p *playwrightPage) Close() error {
+	if err := p.SaveTrace(""); err != nil {
+		_ = p.context.Close()
+		return err
+	}
+	if err := p.context.Close(); err != nil {
+		return fmt.Errorf("failed to close browser context: %w", err)
+	}
+	return p.finishVideo()
+}
+
+// SaveVideo sets where the video is saved when the page closes
+func (p *playwrightPage) SaveVideo(path string) {
+	p.videoPath = path
+}
+
+// finishVideo moves the recorded video to the path set by SaveVideo, or
+// deletes it. The video is only complete once the context is closed.
+func (p *playwrightPage) finishVideo() error {
+	if p.video == nil {
+		return nil
+	}
+	defer func() { _ = p.video.Delete() }()
+
+	if p.videoPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p.videoPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for video %s: %w", p.videoPath, err)
+	}
+	if err := p.video.SaveAs(p.videoPath); err != nil {
+		return fmt.Errorf("failed to save video %s: %w", p.videoPath, err)
+	}
+	return nil
+}
+
+// SetTimeout sets the default timeout in milliseconds for navigation, actions and waits.
+// A timeout of 0 disables the timeout.
+func (p *playwrightPage) SetTimeout(timeout int) {
+	p.page.SetDefaultTimeout(float64(timeout))
+	p.page.SetDefaultNavigationTimeout(float64(timeout))
+}
+
 // NavigateToURL navigates to the specified URL
 func (p *playwrightPage) NavigateToURL(url string) error {
 	_, err := p.page.Goto(url)
@@ -147,6 +257,15 @@ func (p *playwrightPage) URL() string {
 	return p.page.URL()
 }
 
+// Title returns the title of the page
+func (p *playwrightPage) Title() (string, error) {
+	title, err := p.page.Title()
+	if err != nil {
+		return "", fmt.Errorf("failed to get page title: %w", err)
+	}
+	return title, nil
+}
+
 // SetContent sets the HTML content of the page
 func (p *playwrightPage) SetContent(html string) error {
 	err := p.page.SetContent(html)
@@ -171,6 +290,25 @@ func (p *playwrightPage) InputValue(selector string) (string, error) {
 	return p.GetElementValue(selector)
 }
 
+// GetElementAttribute returns the value of an attribute of an element
+func (p *playwrightPage) GetElementAttribute(selector, name string) (string, error) {
+	locator := p.page.Locator(selector)
+	value, err := locator.GetAttribute(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get attribute %s for %s: %w", name, selector, err)
+	}
+	return value, nil
+}
+
+// Evaluate evaluates a JavaScript expression or function in the page and returns its result
+func (p *playwrightPage) Evaluate(expression string) (interface{}, error) {
+	result, err := p.page.Evaluate(expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s: %w", expression, err)
+	}
+	return result, nil
+}
+
 // GetElementCount returns the count of elements matching the selector
 func (p *playwrightPage) GetElementCount(selector string) (int, error) {
 	helper := newElementHelper(p)
@@ -188,3 +326,28 @@ func (p *playwrightPage) ElementExists(selector string) (bool, error) {
 	helper := newElementHelper(p)
 	return helper.ElementExists(selector)
 }
+
+// IsEnabled reports whether an element is enabled
+func (p *playwrightPage) IsEnabled(selector string) (bool, error) {
+	helper := newElementHelper(p)
+	return helper.IsEnabled(selector)
+}
+
+// IsVisible reports whether an element is visible
+func (p *playwrightPage) IsVisible(selector string) (bool, error) {
+	helper := newElementHelper(p)
+	return helper.IsVisible(selector)
+}
+
+// GetCSSProperty returns the computed value of a CSS property of an element
+func (p *playwrightPage) GetCSSProperty(selector, property string) (string, error) {
+	helper := newElementHelper(p)
+	return helper.GetCSSProperty(selector, property)
+}
+
+// WaitForSelector waits until an element matching selector reaches the given state
+// (attached, detached, visible or hidden) within the default timeout
+func (p *playwrightPage) WaitForSelector(selector, state string) error {
+	helper := newElementHelper(p)
+	return helper.WaitForSelector(selector, state)
+}
@@ -292,3 +292,47 @@ func TestGetElementValue(t *testing.T) {
 		t.Errorf("Expected value 'new value' after fill, got '%s'", value)
 	}
 }
+
+func TestGetElementAttributeAndEvaluate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	browser, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Failed to create browser: %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.NewPage()
+	if err != nil {
+		t.Fatalf("Failed to create page: %v", err)
+	}
+
+	err = page.SetContent(`<html><body><a id="link" href="/next" data-id="42">Next</a></body></html>`)
+	if err != nil {
+		t.Fatalf("Failed to set content: %v", err)
+	}
+
+	value, err := page.GetElementAttribute("#link", "data-id")
+	if err != nil {
+		t.Fatalf("Failed to get element attribute: %v", err)
+	}
+	if value != "42" {
+		t.Errorf("Expected attribute value '42', got '%s'", value)
+	}
+
+	result, err := page.Evaluate("document.querySelector('#link').textContent")
+	if err != nil {
+		t.Fatalf("Failed to evaluate expression: %v", err)
+	}
+	if result != "Next" {
+		t.Errorf("Expected evaluation result 'Next', got '%v'", result)
+	}
+}
@@ -0,0 +1,61 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Content returns the full HTML of the page
+func (p *playwrightPage) Content() (string, error) {
+	html, err := p.page.Content()
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %w", err)
+	}
+	return html, nil
+}
+
+// Screenshot saves a PNG of the viewport, or of the whole scrollable page
+// when fullPage is true. Missing parent directories are created.
+func (p *playwrightPage) Screenshot(path string, fullPage bool) error {
+	_, err := p.page.Screenshot(playwright.PageScreenshotOptions{
+		Path:     playwright.String(path),
+		FullPage: playwright.Bool(fullPage),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to take screenshot %s: %w", path, err)
+	}
+	return nil
+}
+
+// ElementScreenshot saves a PNG of a single element, scrolling it into view
+func (p *playwrightPage) ElementScreenshot(selector, path string) error {
+	_, err := p.page.Locator(selector).Screenshot(playwright.LocatorScreenshotOptions{
+		Path: playwright.String(path),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to take screenshot of %s: %w", selector, err)
+	}
+	return nil
+}
+
+// SaveTrace stops the trace started by NewPage and writes it to path, or
+// discards it when path is empty. View a saved trace with
+// `npx playwright show-trace <path>`.
+func (p *playwrightPage) SaveTrace(path string) error {
+	if !p.tracing {
+		return nil
+	}
+	p.tracing = false
+
+	var err error
+	if path == "" {
+		err = p.context.Tracing().Stop()
+	} else {
+		err = p.context.Tracing().Stop(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save trace %s: %w", path, err)
+	}
+	return nil
+}
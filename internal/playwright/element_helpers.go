@@ -1,6 +1,10 @@
 package playwright
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
 
 // elementHelper provides element query methods for assertions
 type elementHelper struct {
@@ -40,3 +44,45 @@ func (h *elementHelper) ElementExists(selector string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// IsEnabled reports whether an element is enabled
+func (h *elementHelper) IsEnabled(selector string) (bool, error) {
+	enabled, err := h.page.page.Locator(selector).IsEnabled()
+	if err != nil {
+		return false, fmt.Errorf("failed to get enabled state of %s: %w", selector, err)
+	}
+	return enabled, nil
+}
+
+// IsVisible reports whether the first element matching selector is visible.
+// It does not wait, and reports false when no element matches.
+func (h *elementHelper) IsVisible(selector string) (bool, error) {
+	visible, err := h.page.page.Locator(selector).First().IsVisible()
+	if err != nil {
+		return false, fmt.Errorf("failed to get visibility of %s: %w", selector, err)
+	}
+	return visible, nil
+}
+
+// GetCSSProperty returns the computed value of a CSS property of an element,
+// such as "rgb(255, 0, 0)" for color
+func (h *elementHelper) GetCSSProperty(selector, property string) (string, error) {
+	result, err := h.page.page.Locator(selector).Evaluate(
+		"(el, property) => getComputedStyle(el).getPropertyValue(property)", property)
+	if err != nil {
+		return "", fmt.Errorf("failed to get CSS property %s of %s: %w", property, selector, err)
+	}
+	value, _ := result.(string)
+	return value, nil
+}
+
+// WaitForSelector waits for the first element matching selector to reach state
+func (h *elementHelper) WaitForSelector(selector, state string) error {
+	waitState := playwright.WaitForSelectorState(state)
+	locator := h.page.page.Locator(selector).First()
+	err := locator.WaitFor(playwright.LocatorWaitForOptions{State: &waitState})
+	if err != nil {
+		return fmt.Errorf("failed waiting for %s to be %s: %w", selector, state, err)
+	}
+	return nil
+}
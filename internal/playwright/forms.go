@@ -0,0 +1,85 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/playwright-community/playwright-go"
+)
+
+// SelectOption selects the options of a <select> element matching the given
+// values, labels or indexes, replacing the current selection. Several
+// options can only be selected in a <select multiple>.
+func (p *playwrightPage) SelectOption(selector string, options browser.SelectOptions) error {
+	values := playwright.SelectOptionValues{}
+	if options.Values != nil {
+		values.Values = &options.Values
+	}
+	if options.Labels != nil {
+		values.Labels = &options.Labels
+	}
+	if options.Indexes != nil {
+		values.Indexes = &options.Indexes
+	}
+
+	if _, err := p.page.Locator(selector).SelectOption(values); err != nil {
+		return fmt.Errorf("failed to select options in %s: %w", selector, err)
+	}
+	return nil
+}
+
+// Check checks a checkbox or radio button
+func (p *playwrightPage) Check(selector string) error {
+	if err := p.page.Locator(selector).Check(); err != nil {
+		return fmt.Errorf("failed to check %s: %w", selector, err)
+	}
+	return nil
+}
+
+// Uncheck unchecks a checkbox
+func (p *playwrightPage) Uncheck(selector string) error {
+	if err := p.page.Locator(selector).Uncheck(); err != nil {
+		return fmt.Errorf("failed to uncheck %s: %w", selector, err)
+	}
+	return nil
+}
+
+// SetInputFiles sets the files of an <input type="file">. An empty list
+// clears the selected files.
+func (p *playwrightPage) SetInputFiles(selector string, paths []string) error {
+	if err := p.page.Locator(selector).SetInputFiles(paths); err != nil {
+		return fmt.Errorf("failed to set files of %s: %w", selector, err)
+	}
+	return nil
+}
+
+// IsChecked reports whether a checkbox or radio button is checked
+func (p *playwrightPage) IsChecked(selector string) (bool, error) {
+	checked, err := p.page.Locator(selector).IsChecked()
+	if err != nil {
+		return false, fmt.Errorf("failed to get checked state of %s: %w", selector, err)
+	}
+	return checked, nil
+}
+
+// GetSelectedOptions returns the selected options of a <select> element
+func (p *playwrightPage) GetSelectedOptions(selector string) ([]browser.Option, error) {
+	result, err := p.page.Locator(selector).Evaluate(
+		"el => Array.from(el.selectedOptions, o => [o.value, o.label])", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get selected options of %s: %w", selector, err)
+	}
+
+	pairs, _ := result.([]interface{})
+	options := make([]browser.Option, 0, len(pairs))
+	for _, pair := range pairs {
+		fields, ok := pair.([]interface{})
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected selected options of %s: %v", selector, result)
+		}
+		value, _ := fields[0].(string)
+		label, _ := fields[1].(string)
+		options = append(options, browser.Option{Value: value, Label: label})
+	}
+	return options, nil
+}
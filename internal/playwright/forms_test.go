@@ -0,0 +1,94 @@
+package playwright
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestFormControls(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	b, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Failed to create browser: %v", err)
+	}
+	defer b.Close()
+
+	page, err := b.NewPage()
+	if err != nil {
+		t.Fatalf("Failed to create page: %v", err)
+	}
+
+	html := `<html><body>
+		<select id="size"><option value="s">Small</option><option value="m">Medium</option><option value="l">Large</option></select>
+		<select id="toppings" multiple><option value="c">Cheese</option><option value="o">Olives</option><option value="p">Peppers</option></select>
+		<input id="terms" type="checkbox">
+		<input id="newsletter" type="checkbox" checked>
+		<input id="avatar" type="file">
+	</body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Failed to set content: %v", err)
+	}
+
+	assertion := NewAssertion(page)
+
+	if err := page.SelectOption("#size", browser.SelectOptions{Indexes: []int{2}}); err != nil {
+		t.Fatalf("Failed to select by index: %v", err)
+	}
+	if err := assertion.AssertSelected("#size", []string{"l"}, nil); err != nil {
+		t.Errorf("Expected size 'l' to be selected: %v", err)
+	}
+
+	if err := page.SelectOption("#toppings", browser.SelectOptions{Labels: []string{"Olives", "Cheese"}}); err != nil {
+		t.Fatalf("Failed to select by labels: %v", err)
+	}
+	if err := assertion.AssertSelected("#toppings", nil, []string{"Cheese", "Olives"}); err != nil {
+		t.Errorf("Expected Cheese and Olives to be selected: %v", err)
+	}
+	if err := assertion.AssertSelected("#toppings", []string{"c"}, nil); err == nil {
+		t.Error("Expected error for partially matching selection, got nil")
+	}
+
+	if err := page.Check("#terms"); err != nil {
+		t.Fatalf("Failed to check: %v", err)
+	}
+	if err := page.Uncheck("#newsletter"); err != nil {
+		t.Fatalf("Failed to uncheck: %v", err)
+	}
+	if err := assertion.AssertChecked("#terms"); err != nil {
+		t.Errorf("Expected #terms to be checked: %v", err)
+	}
+	if err := assertion.AssertUnchecked("#newsletter"); err != nil {
+		t.Errorf("Expected #newsletter to be unchecked: %v", err)
+	}
+	if err := assertion.AssertChecked("#newsletter"); err == nil {
+		t.Error("Expected error for unchecked checkbox, got nil")
+	}
+
+	file := filepath.Join(t.TempDir(), "avatar.png")
+	if err := os.WriteFile(file, []byte("png"), 0o600); err != nil {
+		t.Fatalf("Failed to write upload file: %v", err)
+	}
+	if err := page.SetInputFiles("#avatar", []string{file}); err != nil {
+		t.Fatalf("Failed to set input files: %v", err)
+	}
+	name, err := page.Evaluate("document.querySelector('#avatar').files[0].name")
+	if err != nil {
+		t.Fatalf("Failed to read uploaded file name: %v", err)
+	}
+	if name != "avatar.png" {
+		t.Errorf("Expected uploaded file 'avatar.png', got %v", name)
+	}
+}
@@ -0,0 +1,64 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Press presses a key or a chord such as "Enter" or "Control+K". The key is
+// sent to the element matching selector, or to the focused element when
+// selector is empty.
+func (p *playwrightPage) Press(selector, key string) error {
+	var err error
+	if selector == "" {
+		err = p.page.Keyboard().Press(key)
+	} else {
+		err = p.page.Locator(selector).Press(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to press %s: %w", key, err)
+	}
+	return nil
+}
+
+// Type types text one key at a time, waiting delay milliseconds between keys,
+// into the element matching selector or the focused element when selector is
+// empty. Unlike Fill it triggers keydown, keypress and keyup for every character.
+func (p *playwrightPage) Type(selector, text string, delay int) error {
+	keyDelay := float64(delay)
+	var err error
+	if selector == "" {
+		err = p.page.Keyboard().Type(text, playwright.KeyboardTypeOptions{Delay: &keyDelay})
+	} else {
+		err = p.page.Locator(selector).PressSequentially(text, playwright.LocatorPressSequentiallyOptions{Delay: &keyDelay})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to type into %s: %w", describeTarget(selector), err)
+	}
+	return nil
+}
+
+// KeyDown holds down a key, such as a modifier for the following steps
+func (p *playwrightPage) KeyDown(key string) error {
+	if err := p.page.Keyboard().Down(key); err != nil {
+		return fmt.Errorf("failed to press down %s: %w", key, err)
+	}
+	return nil
+}
+
+// KeyUp releases a key held down by KeyDown
+func (p *playwrightPage) KeyUp(key string) error {
+	if err := p.page.Keyboard().Up(key); err != nil {
+		return fmt.Errorf("failed to release %s: %w", key, err)
+	}
+	return nil
+}
+
+// describeTarget names the element targeted by a keyboard action
+func describeTarget(selector string) string {
+	if selector == "" {
+		return "focused element"
+	}
+	return selector
+}
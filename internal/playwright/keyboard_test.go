@@ -0,0 +1,78 @@
+package playwright
+
+import (
+	"testing"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestKeyboard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	browser, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Failed to create browser: %v", err)
+	}
+	defer browser.Close()
+
+	page, err := browser.NewPage()
+	if err != nil {
+		t.Fatalf("Failed to create page: %v", err)
+	}
+
+	// Record every keydown so typing key by key can be told apart from filling
+	html := `<html><body>
+		<input id="search">
+		<div id="keys"></div>
+		<script>
+			document.addEventListener('keydown', e => {
+				const key = (e.shiftKey && e.key !== 'Shift' ? 'Shift+' : '') + (e.ctrlKey && e.key !== 'Control' ? 'Control+' : '') + e.key;
+				document.querySelector('#keys').textContent += key + ' ';
+			});
+		</script>
+	</body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Failed to set content: %v", err)
+	}
+
+	if err := page.Type("#search", "abc", 10); err != nil {
+		t.Fatalf("Failed to type: %v", err)
+	}
+	if err := page.Press("#search", "Control+K"); err != nil {
+		t.Fatalf("Failed to press chord: %v", err)
+	}
+	if err := page.KeyDown("Shift"); err != nil {
+		t.Fatalf("Failed to press down key: %v", err)
+	}
+	if err := page.Press("", "KeyX"); err != nil {
+		t.Fatalf("Failed to press key on focused element: %v", err)
+	}
+	if err := page.KeyUp("Shift"); err != nil {
+		t.Fatalf("Failed to release key: %v", err)
+	}
+
+	value, err := page.GetElementValue("#search")
+	if err != nil {
+		t.Fatalf("Failed to get input value: %v", err)
+	}
+	if value != "abcX" {
+		t.Errorf("Expected input value 'abcX', got '%s'", value)
+	}
+
+	keys, err := page.GetElementText("#keys")
+	if err != nil {
+		t.Fatalf("Failed to get recorded keys: %v", err)
+	}
+	expected := "a b c Control+k Shift Shift+X "
+	if keys != expected {
+		t.Errorf("Expected keys %q, got %q", expected, keys)
+	}
+}
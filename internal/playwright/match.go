@@ -0,0 +1,128 @@
+package playwright
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Text match modes
+const (
+	MatchContains   = "contains"
+	MatchEquals     = "equals"
+	MatchRegexp     = "matches"
+	MatchStartsWith = "starts_with"
+	MatchEndsWith   = "ends_with"
+)
+
+// TextMatch describes how text such as an element's text content or the page
+// URL is compared with an expected value
+type TextMatch struct {
+	// Mode is one of the Match constants; empty means MatchContains
+	Mode     string
+	Expected string
+	// NormalizeWhitespace trims both texts and collapses runs of whitespace
+	// into single spaces before comparing
+	NormalizeWhitespace bool
+	IgnoreCase          bool
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// Match reports whether actual matches the expected text. It fails only for
+// an invalid regular expression.
+func (m TextMatch) Match(actual string) (bool, error) {
+	expected := m.Expected
+	if m.NormalizeWhitespace {
+		actual = normalizeWhitespace(actual)
+		if m.Mode != MatchRegexp {
+			expected = normalizeWhitespace(expected)
+		}
+	}
+
+	if m.Mode == MatchRegexp {
+		pattern, err := m.compile()
+		if err != nil {
+			return false, err
+		}
+		return pattern.MatchString(actual), nil
+	}
+
+	if m.IgnoreCase {
+		actual = strings.ToLower(actual)
+		expected = strings.ToLower(expected)
+	}
+	switch m.Mode {
+	case MatchEquals:
+		return actual == expected, nil
+	case MatchStartsWith:
+		return strings.HasPrefix(actual, expected), nil
+	case MatchEndsWith:
+		return strings.HasSuffix(actual, expected), nil
+	case MatchContains, "":
+		return strings.Contains(actual, expected), nil
+	default:
+		return false, fmt.Errorf("unknown match mode: %s", m.Mode)
+	}
+}
+
+// Validate reports an unknown mode or an invalid regular expression
+func (m TextMatch) Validate() error {
+	switch m.Mode {
+	case MatchRegexp:
+		_, err := m.compile()
+		return err
+	case MatchContains, MatchEquals, MatchStartsWith, MatchEndsWith, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown match mode: %s", m.Mode)
+	}
+}
+
+// compile compiles the expected regular expression
+func (m TextMatch) compile() (*regexp.Regexp, error) {
+	expr := m.Expected
+	if m.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", m.Expected, err)
+	}
+	return pattern, nil
+}
+
+// String describes the expectation for messages, e.g. "to start with 'Hello'
+// (ignoring case)"
+func (m TextMatch) String() string {
+	var description string
+	switch m.Mode {
+	case MatchEquals:
+		description = fmt.Sprintf("to equal '%s'", m.Expected)
+	case MatchRegexp:
+		description = fmt.Sprintf("to match /%s/", m.Expected)
+	case MatchStartsWith:
+		description = fmt.Sprintf("to start with '%s'", m.Expected)
+	case MatchEndsWith:
+		description = fmt.Sprintf("to end with '%s'", m.Expected)
+	default:
+		description = fmt.Sprintf("to contain '%s'", m.Expected)
+	}
+
+	var options []string
+	if m.IgnoreCase {
+		options = append(options, "ignoring case")
+	}
+	if m.NormalizeWhitespace {
+		options = append(options, "normalizing whitespace")
+	}
+	if len(options) > 0 {
+		description += " (" + strings.Join(options, ", ") + ")"
+	}
+	return description
+}
+
+// normalizeWhitespace trims s and collapses runs of whitespace into single spaces
+func normalizeWhitespace(s string) string {
+	return whitespace.ReplaceAllString(strings.TrimSpace(s), " ")
+}
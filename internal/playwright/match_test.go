@@ -0,0 +1,64 @@
+package playwright
+
+import "testing"
+
+func TestTextMatch(t *testing.T) {
+	tests := []struct {
+		match   TextMatch
+		actual  string
+		matched bool
+	}{
+		{TextMatch{Mode: MatchContains, Expected: "Welcome"}, "Welcome back", true},
+		{TextMatch{Mode: MatchContains, Expected: "Welcome back"}, "Welcome", false},
+		{TextMatch{Mode: MatchEquals, Expected: "Welcome"}, "Welcome back", false},
+		{TextMatch{Mode: MatchEquals, Expected: "Welcome back"}, "  Welcome\n  back ", false},
+		{TextMatch{Mode: MatchEquals, Expected: "Welcome back", NormalizeWhitespace: true}, "  Welcome\n  back ", true},
+		{TextMatch{Mode: MatchStartsWith, Expected: "welcome", IgnoreCase: true}, "Welcome back", true},
+		{TextMatch{Mode: MatchEndsWith, Expected: "back"}, "Welcome back", true},
+		{TextMatch{Mode: MatchEndsWith, Expected: "Welcome"}, "Welcome back", false},
+		{TextMatch{Mode: MatchRegexp, Expected: `^Order #\d+$`}, "Order #42", true},
+		{TextMatch{Mode: MatchRegexp, Expected: `^order #\d+$`, IgnoreCase: true}, "Order #42", true},
+		{TextMatch{Mode: MatchRegexp, Expected: `^Order #\d+$`, NormalizeWhitespace: true}, " Order #42\n", true},
+	}
+
+	for _, tt := range tests {
+		matched, err := tt.match.Match(tt.actual)
+		if err != nil {
+			t.Fatalf("Expected no error for %+v, got %v", tt.match, err)
+		}
+		if matched != tt.matched {
+			t.Errorf("Expected %q %s to be %v", tt.actual, tt.match, tt.matched)
+		}
+	}
+}
+
+func TestTextMatchInvalid(t *testing.T) {
+	match := TextMatch{Mode: MatchRegexp, Expected: "(unclosed"}
+	if err := match.Validate(); err == nil {
+		t.Error("Expected error for invalid regular expression")
+	}
+	if _, err := match.Match("anything"); err == nil {
+		t.Error("Expected error matching invalid regular expression")
+	}
+	if err := (TextMatch{Mode: "fuzzy"}).Validate(); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestTextMatchString(t *testing.T) {
+	tests := []struct {
+		match    TextMatch
+		expected string
+	}{
+		{TextMatch{Expected: "Hi"}, "to contain 'Hi'"},
+		{TextMatch{Mode: MatchEquals, Expected: "Hi"}, "to equal 'Hi'"},
+		{TextMatch{Mode: MatchRegexp, Expected: `^\d+$`}, `to match /^\d+$/`},
+		{TextMatch{Mode: MatchStartsWith, Expected: "Hi", IgnoreCase: true, NormalizeWhitespace: true}, "to start with 'Hi' (ignoring case, normalizing whitespace)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.match.String(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
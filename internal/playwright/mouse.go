@@ -0,0 +1,92 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/playwright-community/playwright-go"
+)
+
+// Hover moves the mouse over the element matching selector
+func (p *playwrightPage) Hover(selector string) error {
+	if err := p.page.Locator(selector).Hover(); err != nil {
+		return fmt.Errorf("failed to hover over %s: %w", selector, err)
+	}
+	return nil
+}
+
+// ClickWith clicks the element matching selector with the given button,
+// modifier keys, click count and position within the element. Without a
+// selector it clicks options.Position on the page.
+func (p *playwrightPage) ClickWith(selector string, options browser.ClickOptions) error {
+	if selector == "" {
+		return p.clickAt(options)
+	}
+
+	clickOptions := playwright.LocatorClickOptions{}
+	if options.Button != "" {
+		button := playwright.MouseButton(options.Button)
+		clickOptions.Button = &button
+	}
+	if options.ClickCount > 0 {
+		clickOptions.ClickCount = playwright.Int(options.ClickCount)
+	}
+	for _, modifier := range options.Modifiers {
+		clickOptions.Modifiers = append(clickOptions.Modifiers, playwright.KeyboardModifier(modifier))
+	}
+	if options.Position != nil {
+		clickOptions.Position = &playwright.Position{X: options.Position.X, Y: options.Position.Y}
+	}
+
+	if err := p.page.Locator(selector).Click(clickOptions); err != nil {
+		return fmt.Errorf("failed to click element %s: %w", selector, err)
+	}
+	return nil
+}
+
+// clickAt clicks a point on the page, holding the modifier keys down
+// for the duration of the click
+func (p *playwrightPage) clickAt(options browser.ClickOptions) error {
+	if options.Position == nil {
+		return fmt.Errorf("click requires a selector or a position")
+	}
+
+	mouseOptions := playwright.MouseClickOptions{}
+	if options.Button != "" {
+		button := playwright.MouseButton(options.Button)
+		mouseOptions.Button = &button
+	}
+	if options.ClickCount > 0 {
+		mouseOptions.ClickCount = playwright.Int(options.ClickCount)
+	}
+
+	keyboard := p.page.Keyboard()
+	for i, modifier := range options.Modifiers {
+		if err := keyboard.Down(modifier); err != nil {
+			releaseKeys(keyboard, options.Modifiers[:i])
+			return fmt.Errorf("failed to press down %s: %w", modifier, err)
+		}
+	}
+	err := p.page.Mouse().Click(options.Position.X, options.Position.Y, mouseOptions)
+	releaseKeys(keyboard, options.Modifiers)
+	if err != nil {
+		return fmt.Errorf("failed to click at (%g, %g): %w", options.Position.X, options.Position.Y, err)
+	}
+	return nil
+}
+
+// releaseKeys releases keys held down, in reverse order
+func releaseKeys(keyboard playwright.Keyboard, keys []string) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		_ = keyboard.Up(keys[i])
+	}
+}
+
+// DragAndDrop drags the element matching source onto the element matching target
+func (p *playwrightPage) DragAndDrop(source, target string) error {
+	err := p.page.Locator(source).DragTo(p.page.Locator(target))
+	if err != nil {
+		return fmt.Errorf("failed to drag %s to %s: %w", source, target, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package playwright
+
+import (
+	"testing"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestMouse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  30000,
+	}
+
+	b, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Failed to create browser: %v", err)
+	}
+	defer b.Close()
+
+	page, err := b.NewPage()
+	if err != nil {
+		t.Fatalf("Failed to create page: %v", err)
+	}
+
+	// Record mouse events on the target and support a native drag and drop
+	html := `<html><body style="margin:0">
+		<div id="target" style="width:100px;height:100px">target</div>
+		<div id="card" draggable="true">card</div>
+		<div id="done" style="width:100px;height:100px">done</div>
+		<div id="log"></div>
+		<script>
+			const log = s => document.querySelector('#log').textContent += s + ' ';
+			const target = document.querySelector('#target');
+			target.addEventListener('mouseenter', () => log('enter'));
+			target.addEventListener('dblclick', () => log('dblclick'));
+			target.addEventListener('contextmenu', e => { e.preventDefault(); log('contextmenu'); });
+			target.addEventListener('click', e => log('click' + (e.shiftKey ? '+shift' : '') + '@' + e.offsetX + ',' + e.offsetY));
+			const done = document.querySelector('#done');
+			done.addEventListener('dragover', e => e.preventDefault());
+			done.addEventListener('drop', () => log('drop'));
+		</script>
+	</body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Failed to set content: %v", err)
+	}
+
+	if err := page.Hover("#target"); err != nil {
+		t.Fatalf("Failed to hover: %v", err)
+	}
+	if err := page.ClickWith("#target", browser.ClickOptions{ClickCount: 2}); err != nil {
+		t.Fatalf("Failed to double-click: %v", err)
+	}
+	if err := page.ClickWith("#target", browser.ClickOptions{Button: "right"}); err != nil {
+		t.Fatalf("Failed to right-click: %v", err)
+	}
+	if err := page.ClickWith("#target", browser.ClickOptions{Modifiers: []string{"Shift"}, Position: &types.Point{X: 5, Y: 6}}); err != nil {
+		t.Fatalf("Failed to click with modifiers: %v", err)
+	}
+	if err := page.ClickWith("", browser.ClickOptions{Position: &types.Point{X: 10, Y: 20}}); err != nil {
+		t.Fatalf("Failed to click at position: %v", err)
+	}
+	if err := page.DragAndDrop("#card", "#done"); err != nil {
+		t.Fatalf("Failed to drag and drop: %v", err)
+	}
+
+	logged, err := page.GetElementText("#log")
+	if err != nil {
+		t.Fatalf("Failed to get event log: %v", err)
+	}
+	expected := "enter click@50,50 click@50,50 dblclick contextmenu click+shift@5,6 click@10,20 drop "
+	if logged != expected {
+		t.Errorf("Expected events %q, got %q", expected, logged)
+	}
+}
@@ -0,0 +1,36 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// WaitForURL waits until the page URL matches pattern, an exact URL or a glob
+// pattern such as "**/dashboard", within the default navigation timeout
+func (p *playwrightPage) WaitForURL(pattern string) error {
+	if err := p.page.WaitForURL(pattern); err != nil {
+		return fmt.Errorf("failed waiting for URL %s (current URL %s): %w", pattern, p.page.URL(), err)
+	}
+	return nil
+}
+
+// WaitForLoadState waits until the page reaches a load state: load,
+// domcontentloaded or networkidle
+func (p *playwrightPage) WaitForLoadState(state string) error {
+	loadState := playwright.LoadState(state)
+	err := p.page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{State: &loadState})
+	if err != nil {
+		return fmt.Errorf("failed waiting for load state %s: %w", state, err)
+	}
+	return nil
+}
+
+// WaitForFunction waits until a JavaScript expression, or a function such as
+// "() => window.ready", returns a truthy value
+func (p *playwrightPage) WaitForFunction(expression string) error {
+	if _, err := p.page.WaitForFunction(expression, nil); err != nil {
+		return fmt.Errorf("failed waiting for %s: %w", expression, err)
+	}
+	return nil
+}
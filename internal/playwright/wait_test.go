@@ -0,0 +1,64 @@
+package playwright
+
+import (
+	"testing"
+
+	"github.com/haruotsu/ezpw/internal/browser"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func TestWait(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	config := types.Config{
+		Browser:  "chromium",
+		Headless: true,
+		Timeout:  5000,
+	}
+
+	b, err := NewBrowser(config)
+	if err != nil {
+		t.Fatalf("Failed to create browser: %v", err)
+	}
+	defer b.Close()
+
+	page, err := b.NewPage()
+	if err != nil {
+		t.Fatalf("Failed to create page: %v", err)
+	}
+
+	// The spinner goes away and the page marks itself ready after a delay
+	html := `<html><body>
+		<div id="spinner">Loading</div>
+		<script>
+			setTimeout(() => {
+				document.querySelector('#spinner').remove();
+				window.ready = true;
+				history.pushState({}, '', '#done');
+			}, 200);
+		</script>
+	</body></html>`
+	if err := page.SetContent(html); err != nil {
+		t.Fatalf("Failed to set content: %v", err)
+	}
+
+	if err := page.WaitForLoadState(browser.LoadStateLoad); err != nil {
+		t.Errorf("Failed to wait for load state: %v", err)
+	}
+	if err := page.WaitForSelector("#spinner", browser.StateDetached); err != nil {
+		t.Errorf("Failed to wait for spinner to go away: %v", err)
+	}
+	if err := page.WaitForFunction("() => window.ready === true"); err != nil {
+		t.Errorf("Failed to wait for function: %v", err)
+	}
+	if err := page.WaitForURL("**#done"); err != nil {
+		t.Errorf("Failed to wait for URL: %v", err)
+	}
+
+	page.SetTimeout(300)
+	if err := page.WaitForFunction("() => window.never"); err == nil {
+		t.Error("Expected error waiting for a condition that never holds, got nil")
+	}
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+// jsonReport is the machine-readable representation of a run
+type jsonReport struct {
+	StartedAt string         `json:"started_at"`
+	Scenarios []jsonScenario `json:"scenarios"`
+	Summary   jsonSummary    `json:"summary"`
+}
+
+type jsonSummary struct {
+	Total      int     `json:"total"`
+	Passed     int     `json:"passed"`
+	Flaky      int     `json:"flaky"`
+	Failed     int     `json:"failed"`
+	Skipped    int     `json:"skipped"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+type jsonScenario struct {
+	Name       string     `json:"name"`
+	File       string     `json:"file"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Attempts   int        `json:"attempts,omitempty"`
+	Steps      []jsonStep `json:"steps"`
+	Artifacts  []string   `json:"artifacts,omitempty"`
+	DurationMS float64    `json:"duration_ms"`
+
+	// Errors of the failed attempts of a retried scenario
+	RetriedErrors []string `json:"retried_errors,omitempty"`
+}
+
+type jsonStep struct {
+	Type       string  `json:"type"`
+	Location   string  `json:"location,omitempty"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	Index      int     `json:"index"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// WriteJSON writes the results as an indented JSON report
+func WriteJSON(w io.Writer, startedAt time.Time, results []*types.ScenarioResult) error {
+	summary := Summarize(results)
+	doc := jsonReport{
+		StartedAt: startedAt.Format(time.RFC3339),
+		Scenarios: make([]jsonScenario, 0, len(results)),
+		Summary: jsonSummary{
+			Total:      summary.Total,
+			Passed:     summary.Passed,
+			Flaky:      summary.Flaky,
+			Failed:     summary.Failed,
+			Skipped:    summary.Skipped,
+			DurationMS: milliseconds(summary.Duration),
+		},
+	}
+
+	for _, result := range results {
+		scenario := jsonScenario{
+			Name:       result.Name,
+			File:       result.File,
+			Status:     result.Status,
+			Error:      errorString(result.Err),
+			Attempts:   result.Attempts,
+			Steps:      make([]jsonStep, 0, len(result.Steps)),
+			Artifacts:  result.Artifacts,
+			DurationMS: milliseconds(result.Duration),
+		}
+		for _, err := range result.RetriedErrs {
+			scenario.RetriedErrors = append(scenario.RetriedErrors, errorString(err))
+		}
+		for _, step := range result.Steps {
+			scenario.Steps = append(scenario.Steps, jsonStep{
+				Index:      step.Index,
+				Type:       step.Type,
+				Location:   step.Pos.String(),
+				Status:     step.Status,
+				Error:      errorString(step.Err),
+				DurationMS: milliseconds(step.Duration),
+			})
+		}
+		doc.Scenarios = append(doc.Scenarios, scenario)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
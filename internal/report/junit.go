@@ -0,0 +1,155 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+}
+
+type junitTestCase struct {
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	SystemOut string        `xml:"system-out,omitempty"`
+
+	// Failures of the attempts before a retried scenario passed, in the
+	// form of Maven Surefire reports understood by CI servers
+	FlakyFailures []junitFailure `xml:"flakyFailure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report with one test case per scenario
+func WriteJUnit(w io.Writer, startedAt time.Time, results []*types.ScenarioResult) error {
+	summary := Summarize(results)
+	suite := junitTestSuite{
+		Name:      "ezpw",
+		Timestamp: startedAt.Format(time.RFC3339),
+		Time:      seconds(summary.Duration),
+		Tests:     summary.Total,
+		Failures:  summary.Failed,
+		Skipped:   summary.Skipped,
+	}
+
+	for _, result := range results {
+		suite.TestCases = append(suite.TestCases, junitCase(result))
+	}
+
+	doc := junitTestSuites{
+		Name:     "ezpw",
+		Time:     suite.Time,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitCase converts a scenario result into a JUnit test case
+func junitCase(result *types.ScenarioResult) junitTestCase {
+	name := result.Name
+	if name == "" {
+		name = result.File
+	}
+
+	testCase := junitTestCase{
+		Name:      name,
+		ClassName: result.File,
+		Time:      seconds(result.Duration),
+		SystemOut: stepLog(result.Steps) + attachments(result.Artifacts),
+	}
+
+	switch result.Status {
+	case types.StatusFailed:
+		testCase.Failure = &junitFailure{
+			Message: errorString(result.Err),
+			Type:    "failure",
+			Text:    errorString(result.Err),
+		}
+	case types.StatusSkipped:
+		testCase.Skipped = &struct{}{}
+	case types.StatusFlaky:
+		for _, err := range result.RetriedErrs {
+			testCase.FlakyFailures = append(testCase.FlakyFailures, junitFailure{
+				Message: errorString(err),
+				Type:    "failure",
+				Text:    errorString(err),
+			})
+		}
+	}
+
+	return testCase
+}
+
+// stepLog renders one line per step with its status and duration
+func stepLog(steps []types.StepResult) string {
+	var b strings.Builder
+	for _, step := range steps {
+		fmt.Fprintf(&b, "step %d: %s %s (%s)", step.Index, step.Type, step.Status, step.Duration.Round(time.Millisecond))
+		if step.Pos.IsValid() {
+			fmt.Fprintf(&b, " at %s", step.Pos)
+		}
+		if step.Err != nil {
+			fmt.Fprintf(&b, ": %v", step.Err)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// attachments lists saved files in the [[ATTACHMENT|path]] form that CI
+// servers such as Jenkins link from the test case
+func attachments(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "[[ATTACHMENT|%s]]\n", path)
+	}
+	return b.String()
+}
+
+// seconds formats a duration as fractional seconds, as JUnit expects
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
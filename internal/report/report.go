@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+const (
+	// JUnitFileName is the name of the JUnit XML report in the output directory
+	JUnitFileName = "junit.xml"
+	// JSONFileName is the name of the JSON report in the output directory
+	JSONFileName = "results.json"
+)
+
+// Summary holds aggregated counts for a test run
+type Summary struct {
+	Total    int
+	Passed   int
+	Flaky    int // Passed on a retry
+	Failed   int
+	Skipped  int
+	Duration time.Duration
+}
+
+// Summarize aggregates scenario results into a Summary
+func Summarize(results []*types.ScenarioResult) Summary {
+	summary := Summary{Total: len(results)}
+	for _, result := range results {
+		switch result.Status {
+		case types.StatusPassed:
+			summary.Passed++
+		case types.StatusFlaky:
+			summary.Flaky++
+		case types.StatusFailed:
+			summary.Failed++
+		case types.StatusSkipped:
+			summary.Skipped++
+		}
+		summary.Duration += result.Duration
+	}
+	return summary
+}
+
+// Write writes the JUnit XML and JSON reports for a run into dir
+func Write(dir string, startedAt time.Time, results []*types.ScenarioResult) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", dir, err)
+	}
+
+	if err := writeFile(filepath.Join(dir, JUnitFileName), func(f *os.File) error {
+		return WriteJUnit(f, startedAt, results)
+	}); err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(dir, JSONFileName), func(f *os.File) error {
+		return WriteJSON(f, startedAt, results)
+	})
+}
+
+// writeFile creates path and fills it using write
+func writeFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+	return nil
+}
+
+// errorString returns the message of err or an empty string when err is nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// milliseconds converts a duration into fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
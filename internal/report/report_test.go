@@ -0,0 +1,181 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+func testResults() []*types.ScenarioResult {
+	return []*types.ScenarioResult{
+		{
+			Name:     "Login test",
+			File:     "tests/login.yml",
+			Status:   types.StatusPassed,
+			Duration: 1500 * time.Millisecond,
+			Steps: []types.StepResult{
+				{Index: 1, Type: "goto", Status: types.StatusPassed, Duration: time.Second},
+				{Index: 2, Type: "click", Status: types.StatusPassed, Duration: 500 * time.Millisecond},
+			},
+		},
+		{
+			Name:     "Checkout test",
+			File:     "tests/checkout.yml",
+			Status:   types.StatusFailed,
+			Err:      errors.New("step 2 failed: element not found"),
+			Duration: 2 * time.Second,
+			Steps: []types.StepResult{
+				{Index: 1, Type: "goto", Status: types.StatusPassed, Duration: time.Second},
+				{Index: 2, Type: "click", Status: types.StatusFailed, Err: errors.New("element not found"), Duration: time.Second},
+				{Index: 3, Type: "assert", Status: types.StatusSkipped},
+			},
+			Artifacts: []string{"reports/checkout-step-2-failure.png", "reports/checkout-step-2-failure.html"},
+		},
+		{
+			File:   "tests/skipped.yml",
+			Status: types.StatusSkipped,
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(testResults())
+
+	if summary.Total != 3 || summary.Passed != 1 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Errorf("Unexpected summary counts: %+v", summary)
+	}
+	if summary.Duration != 3500*time.Millisecond {
+		t.Errorf("Expected total duration 3.5s, got %s", summary.Duration)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJUnit(&buf, time.Now(), testResults())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("Unexpected totals: tests=%d failures=%d skipped=%d", doc.Tests, doc.Failures, doc.Skipped)
+	}
+
+	cases := doc.Suites[0].TestCases
+	if cases[0].Name != "Login test" || cases[0].ClassName != "tests/login.yml" || cases[0].Time != "1.500" {
+		t.Errorf("Unexpected passed test case: %+v", cases[0])
+	}
+	if cases[1].Failure == nil || !strings.Contains(cases[1].Failure.Message, "element not found") {
+		t.Errorf("Expected failure with error message, got %+v", cases[1].Failure)
+	}
+	if !strings.Contains(cases[1].SystemOut, "step 3: assert skipped") {
+		t.Errorf("Expected step log in system-out, got %q", cases[1].SystemOut)
+	}
+	if !strings.Contains(cases[1].SystemOut, "[[ATTACHMENT|reports/checkout-step-2-failure.png]]") {
+		t.Errorf("Expected failure screenshot attachment in system-out, got %q", cases[1].SystemOut)
+	}
+	if cases[2].Skipped == nil || cases[2].Name != "tests/skipped.yml" {
+		t.Errorf("Expected skipped test case named after its file, got %+v", cases[2])
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJSON(&buf, time.Now(), testResults())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var doc jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	if doc.Summary.Total != 3 || doc.Summary.Failed != 1 {
+		t.Errorf("Unexpected summary: %+v", doc.Summary)
+	}
+
+	failed := doc.Scenarios[1]
+	if failed.Status != types.StatusFailed || failed.Error == "" {
+		t.Errorf("Expected failed scenario with error, got %+v", failed)
+	}
+	if failed.Steps[1].Index != 2 || failed.Steps[1].Status != types.StatusFailed || failed.Steps[1].Error != "element not found" {
+		t.Errorf("Unexpected failed step: %+v", failed.Steps[1])
+	}
+	if len(failed.Artifacts) != 2 || failed.Artifacts[1] != "reports/checkout-step-2-failure.html" {
+		t.Errorf("Expected failure artifacts, got %v", failed.Artifacts)
+	}
+	if failed.Steps[0].DurationMS != 1000 {
+		t.Errorf("Expected step duration 1000ms, got %v", failed.Steps[0].DurationMS)
+	}
+}
+
+func TestFlakyScenario(t *testing.T) {
+	results := []*types.ScenarioResult{{
+		Name:        "Search test",
+		File:        "tests/search.yml",
+		Status:      types.StatusFlaky,
+		Duration:    3 * time.Second,
+		Attempts:    2,
+		RetriedErrs: []error{errors.New("step 3 failed: timeout")},
+	}}
+
+	if summary := Summarize(results); summary.Flaky != 1 || summary.Passed != 0 || summary.Failed != 0 {
+		t.Errorf("Expected one flaky scenario, got %+v", summary)
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := WriteJSON(&jsonBuf, time.Now(), results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var doc jsonReport
+	if err := json.Unmarshal(jsonBuf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	flaky := doc.Scenarios[0]
+	if flaky.Status != types.StatusFlaky || flaky.Attempts != 2 || len(flaky.RetriedErrors) != 1 || doc.Summary.Flaky != 1 {
+		t.Errorf("Expected flaky scenario with its retried error, got %+v", doc)
+	}
+
+	var xmlBuf bytes.Buffer
+	if err := WriteJUnit(&xmlBuf, time.Now(), results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(xmlBuf.String(), `<flakyFailure message="step 3 failed: timeout" type="failure">`) {
+		t.Errorf("Expected flakyFailure element, got:\n%s", xmlBuf.String())
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(xmlBuf.Bytes(), &suites); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+	if suites.Failures != 0 || suites.Suites[0].TestCases[0].Failure != nil {
+		t.Errorf("Expected flaky scenario not to count as a failure, got %+v", suites)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+
+	err := Write(dir, time.Now(), testResults())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{JUnitFileName, JSONFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected report %s to be written: %v", name, err)
+		}
+	}
+}
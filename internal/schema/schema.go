@@ -0,0 +1,310 @@
+package schema
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/haruotsu/ezpw/internal/executor"
+	"github.com/haruotsu/ezpw/internal/parser"
+	"github.com/haruotsu/ezpw/pkg/types"
+)
+
+// draft is the JSON Schema dialect of the generated schema, the newest one
+// supported by the VS Code YAML extension
+const draft = "http://json-schema.org/draft-07/schema#"
+
+// object is a JSON Schema object
+type object = map[string]interface{}
+
+// scenarioProperties are the schemas of the top-level keys of a scenario file,
+// by name. The keys themselves come from the parser.
+var scenarioProperties = map[string]object{
+	"desc": {"type": "string", "description": "Description of the scenario"},
+	"vars": {
+		"type":                 "object",
+		"description":          "Variables available to steps as ${NAME}",
+		"additionalProperties": object{"type": []string{"string", "number", "boolean", "null"}},
+	},
+	"timeout": {"type": "integer", "minimum": 0, "description": "Overall deadline of the scenario in milliseconds"},
+	"retries": {
+		"type":        "integer",
+		"minimum":     0,
+		"description": "How many times to rerun the scenario after a failure, overriding --retries",
+	},
+	"video": {
+		"enum":        []string{types.RecordOff, types.RecordOn, types.RecordRetainOnFailure},
+		"description": "When to keep a video of the scenario, overriding --video",
+	},
+	"soft_assertions": {
+		"type":        "boolean",
+		"description": "Record failed assertions and continue, failing the scenario at the end",
+	},
+	"groups": {
+		"type":                 "object",
+		"description":          "Named, parameterized step groups invoked with use",
+		"additionalProperties": object{"$ref": "#/definitions/group"},
+	},
+	"steps": {"$ref": "#/definitions/steps"},
+}
+
+// conditionProperties are the schemas of the keys of an if or unless
+// condition, by name. The keys themselves come from the parser.
+var conditionProperties = map[string]object{
+	"exists":      {"type": "string", "description": "Holds when an element matches the selector"},
+	"visible":     {"type": "string", "description": "Holds when an element matching the selector is visible"},
+	"url":         {"type": "string", "description": "Holds when the page URL contains the text"},
+	"url_matches": {"type": "string", "description": "Holds when the page URL matches the regular expression"},
+	"var":         {"type": "string", "description": "Holds when the variable is non-empty, or compares as equals or not_equals says"},
+	"equals":      {"type": []string{"string", "number", "boolean"}, "description": "Value the variable must equal"},
+	"not_equals":  {"type": []string{"string", "number", "boolean"}, "description": "Value the variable must differ from"},
+}
+
+// Generate builds the JSON Schema of the scenario file format from the step
+// types known to the parser and the assertion types known to the executor
+func Generate() map[string]interface{} {
+	return object{
+		"$schema":              draft,
+		"title":                "ezpw scenario",
+		"description":          "A test scenario run by ezpw",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties(parser.ScenarioKeys(), scenarioProperties),
+		"definitions":          definitions(),
+	}
+}
+
+// properties returns the schemas of keys. A key without a schema accepts any
+// value, so the schema never rejects a key the parser accepts.
+func properties(keys []string, schemas map[string]object) object {
+	props := make(object, len(keys))
+	for _, key := range keys {
+		schema, ok := schemas[key]
+		if !ok {
+			schema = object{}
+		}
+		props[key] = schema
+	}
+	return props
+}
+
+// Write writes the JSON Schema of the scenario file format to w
+func Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Generate())
+}
+
+func definitions() object {
+	modifiers := parser.StepModifiers()
+
+	defs := object{
+		"steps": object{
+			"type":        "array",
+			"description": "Steps executed in order",
+			"items":       object{"$ref": "#/definitions/step"},
+		},
+		"group": object{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"steps"},
+			"properties": object{
+				"params": object{"type": "array", "items": object{"type": "string"}, "description": "Parameter names referenced as ${name} in the steps"},
+				"steps":  object{"$ref": "#/definitions/steps"},
+			},
+		},
+		"include": object{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"include"},
+			"properties": object{
+				"include": object{"type": "string", "description": "Inline the steps of another file, relative to this one"},
+			},
+		},
+		"use": object{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"use"},
+			"properties": object{
+				"use":  object{"type": "string", "description": "Run the steps of a named group"},
+				"with": object{"type": "object", "description": "Values of the group's parameters"},
+			},
+		},
+		"condition": object{
+			"type":                 "object",
+			"additionalProperties": false,
+			"oneOf":                requiredAlternatives(parser.ConditionChecks()),
+			"properties":           properties(parser.ConditionKeys(), conditionProperties),
+		},
+	}
+
+	ifBlock := object{
+		"then": object{"$ref": "#/definitions/steps"},
+		"else": object{"$ref": "#/definitions/steps"},
+	}
+	for _, modifier := range modifiers {
+		ifBlock[modifier.Name] = fieldSchema(modifier)
+	}
+	defs["if_block"] = object{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"then"},
+		"oneOf":                requiredAlternatives([]string{"if", "unless"}),
+		"properties":           ifBlock,
+	}
+
+	refs := []interface{}{
+		object{"$ref": "#/definitions/include"},
+		object{"$ref": "#/definitions/use"},
+		object{"$ref": "#/definitions/if_block"},
+	}
+	for _, stepType := range parser.StepTypes() {
+		value := stepValue(stepType)
+		for _, name := range append([]string{stepType.Name}, stepType.Aliases...) {
+			properties := object{name: value}
+			for _, modifier := range modifiers {
+				properties[modifier.Name] = fieldSchema(modifier)
+			}
+			defs[name] = object{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{name},
+				"properties":           properties,
+			}
+			refs = append(refs, object{"$ref": "#/definitions/" + name})
+		}
+	}
+	defs["step"] = object{"oneOf": refs}
+
+	return defs
+}
+
+// stepValue returns the schema of the value of a step's action key
+func stepValue(stepType parser.StepType) object {
+	var value object
+	switch {
+	case stepType.Scalar != nil && stepType.Fields != nil:
+		value = object{"anyOf": []interface{}{fieldSchema(*stepType.Scalar), fieldsSchema(stepType)}}
+	case stepType.Scalar != nil:
+		value = fieldSchema(*stepType.Scalar)
+	default:
+		value = fieldsSchema(stepType)
+	}
+	value["description"] = stepType.Description
+	return value
+}
+
+// fieldsSchema returns the schema of the mapping form of a step
+func fieldsSchema(stepType parser.StepType) object {
+	properties := object{}
+	var required []string
+	for _, field := range stepType.Fields {
+		properties[field.Name] = fieldSchema(field)
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := object{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(stepType.RequireAny) > 0 {
+		schema["anyOf"] = requiredAlternatives(stepType.RequireAny)
+	}
+	if len(stepType.RequireOne) > 0 {
+		schema["oneOf"] = requiredAlternatives(stepType.RequireOne)
+	}
+	if stepType.Name == "assert" {
+		addAssertionTypes(schema)
+	}
+	return schema
+}
+
+// addAssertionTypes restricts the type of an assert step to the assertion
+// types of the executor and requires the keys each of them needs
+func addAssertionTypes(schema object) {
+	assertionTypes := executor.AssertionTypes()
+
+	names := make([]string, len(assertionTypes))
+	var conditions []interface{}
+	for i, assertionType := range assertionTypes {
+		names[i] = assertionType.Name
+		then := object{}
+		if len(assertionType.Required) > 0 {
+			then["required"] = assertionType.Required
+		}
+		if len(assertionType.RequireOne) > 0 {
+			then["oneOf"] = requiredAlternatives(assertionType.RequireOne)
+		}
+		if len(assertionType.RequireAny) > 0 {
+			then["anyOf"] = requiredAlternatives(assertionType.RequireAny)
+		}
+		conditions = append(conditions, object{
+			"if":   object{"properties": object{"type": object{"const": assertionType.Name}}},
+			"then": then,
+		})
+	}
+
+	typeSchema := schema["properties"].(object)["type"].(object)
+	typeSchema["enum"] = names
+	schema["allOf"] = conditions
+}
+
+// requiredAlternatives returns one schema requiring each of names
+func requiredAlternatives(names []string) []interface{} {
+	alternatives := make([]interface{}, len(names))
+	for i, name := range names {
+		alternatives[i] = object{"required": []string{name}}
+	}
+	return alternatives
+}
+
+// fieldSchema returns the schema of a single step key
+func fieldSchema(field parser.Field) object {
+	schema := object{"type": field.Kind}
+	switch {
+	case field.Kind == "list":
+		items := object{"type": "string"}
+		if len(field.Enum) > 0 {
+			items["enum"] = field.Enum
+		}
+		schema = object{"type": "array", "items": items}
+	case field.Kind == "strings":
+		schema = object{"anyOf": []interface{}{
+			object{"type": []string{"string", "number", "boolean"}},
+			object{"type": "array", "items": object{"type": []string{"string", "number", "boolean"}}},
+		}}
+	case field.Kind == "integers":
+		schema = object{"anyOf": []interface{}{
+			object{"type": "integer"},
+			object{"type": "array", "items": object{"type": "integer"}},
+		}}
+	case field.Kind == "point":
+		schema = object{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []string{"x", "y"},
+			"properties":           object{"x": object{"type": "number"}, "y": object{"type": "number"}},
+		}
+	case field.Kind == "steps":
+		schema = object{"$ref": "#/definitions/steps"}
+	case field.Kind == "condition":
+		schema = object{"$ref": "#/definitions/condition"}
+	case len(field.Enum) > 0:
+		schema["enum"] = field.Enum
+	case field.Kind == "string":
+		// The parser reads any scalar as a string, e.g. value: 42
+		schema["type"] = []string{"string", "number", "boolean"}
+	}
+	if field.Description != "" {
+		schema["description"] = field.Description
+	}
+	if field.Minimum != nil {
+		schema["minimum"] = *field.Minimum
+	}
+	return schema
+}
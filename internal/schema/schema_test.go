@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/haruotsu/ezpw/internal/executor"
+	"github.com/haruotsu/ezpw/internal/parser"
+)
+
+// decode writes the schema and decodes it back as generic JSON
+func decode(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	return decoded
+}
+
+func TestSchemaCoversStepTypes(t *testing.T) {
+	definitions := decode(t)["definitions"].(map[string]interface{})
+
+	refs := map[string]bool{}
+	for _, ref := range definitions["step"].(map[string]interface{})["oneOf"].([]interface{}) {
+		refs[ref.(map[string]interface{})["$ref"].(string)] = true
+	}
+
+	names := []string{"include", "use", "if_block"}
+	for _, stepType := range parser.StepTypes() {
+		names = append(names, stepType.Name)
+		names = append(names, stepType.Aliases...)
+	}
+	for _, name := range names {
+		if !refs["#/definitions/"+name] {
+			t.Errorf("Expected step %q in the schema", name)
+		}
+		if _, ok := definitions[name]; !ok {
+			t.Errorf("Expected definition for step %q", name)
+		}
+	}
+
+	click := definitions["click"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := click["timeout"]; !ok {
+		t.Error("Expected timeout modifier on click step")
+	}
+	if ref := click["if"].(map[string]interface{})["$ref"]; ref != "#/definitions/condition" {
+		t.Errorf("Expected if modifier on click step to reference the condition, got %v", ref)
+	}
+}
+
+func TestSchemaAssertionTypes(t *testing.T) {
+	definitions := decode(t)["definitions"].(map[string]interface{})
+	assert := definitions["assert"].(map[string]interface{})["properties"].(map[string]interface{})["assert"].(map[string]interface{})
+	enum := assert["properties"].(map[string]interface{})["type"].(map[string]interface{})["enum"].([]interface{})
+
+	assertionTypes := executor.AssertionTypes()
+	if len(enum) != len(assertionTypes) {
+		t.Fatalf("Expected %d assertion types, got %v", len(assertionTypes), enum)
+	}
+	for i, assertionType := range assertionTypes {
+		if enum[i] != assertionType.Name {
+			t.Errorf("Expected assertion type %q, got %v", assertionType.Name, enum[i])
+		}
+	}
+
+	conditions := assert["allOf"].([]interface{})
+	if len(conditions) != len(assertionTypes) {
+		t.Fatalf("Expected required keys for each assertion type, got %d conditions", len(conditions))
+	}
+	for i, assertionType := range assertionTypes {
+		then := conditions[i].(map[string]interface{})["then"].(map[string]interface{})
+		if _, ok := then["required"]; ok != (len(assertionType.Required) > 0) {
+			t.Errorf("Unexpected required keys for %s assertion: %v", assertionType.Name, then)
+		}
+		if assertionType.Name == "count" && len(then["anyOf"].([]interface{})) != 3 {
+			t.Errorf("Expected count assertion to require equals, min or max, got %v", then)
+		}
+	}
+}
+
+func TestSchemaMatchesParserKeys(t *testing.T) {
+	decoded := decode(t)
+	condition := decoded["definitions"].(map[string]interface{})["condition"].(map[string]interface{})
+
+	tests := []struct {
+		schemas    map[string]object
+		properties interface{}
+		name       string
+		keys       []string
+	}{
+		{name: "scenario", keys: parser.ScenarioKeys(), schemas: scenarioProperties, properties: decoded["properties"]},
+		{name: "condition", keys: parser.ConditionKeys(), schemas: conditionProperties, properties: condition["properties"]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := map[string]bool{}
+			for _, key := range tt.keys {
+				known[key] = true
+				if _, ok := tt.schemas[key]; !ok {
+					t.Errorf("Expected a schema for %s key %q accepted by the parser", tt.name, key)
+				}
+			}
+			for key := range tt.schemas {
+				if !known[key] {
+					t.Errorf("Schema describes %s key %q the parser does not accept", tt.name, key)
+				}
+			}
+			if got := len(tt.properties.(map[string]interface{})); got != len(tt.keys) {
+				t.Errorf("Expected %d %s properties in the schema, got %d", len(tt.keys), tt.name, got)
+			}
+		})
+	}
+
+	if got := len(condition["oneOf"].([]interface{})); got != len(parser.ConditionChecks()) {
+		t.Errorf("Expected one alternative per condition check, got %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package vars
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UndefinedError is returned when a ${...} reference names an unknown variable
+type UndefinedError struct {
+	Name string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("undefined variable: %s", e.Name)
+}
+
+// Expand replaces ${NAME} references in s using lookup.
+// "$${" escapes a literal "${". Unknown names yield an *UndefinedError.
+func Expand(s string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		// "$${" is an escaped literal "${"
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start-1])
+			b.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+
+		name := strings.TrimSpace(s[start+2 : start+end])
+		if name == "" {
+			return "", fmt.Errorf("empty variable reference in %q", s)
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			return "", &UndefinedError{Name: name}
+		}
+
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// ParseAssignment parses a "key=value" pair as given on the command line
+func ParseAssignment(assignment string) (string, string, error) {
+	key, value, ok := strings.Cut(assignment, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value", assignment)
+	}
+	return key, value, nil
+}
+
+// Substitute replaces only the ${NAME} references whose name is present in
+// values. Other references and "$${" escapes are left untouched so they can
+// be expanded later by Expand.
+func Substitute(s string, values map[string]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		escaped := start > 0 && s[start-1] == '$'
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		reference := s[start : start+end+1]
+		value, ok := values[strings.TrimSpace(s[start+2:start+end])]
+		b.WriteString(s[:start])
+		if ok && !escaped {
+			b.WriteString(value)
+		} else {
+			b.WriteString(reference)
+		}
+		s = s[start+end+1:]
+	}
+}
@@ -0,0 +1,100 @@
+package vars
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{
+		"BASE_URL": "https://staging.example.com",
+		"USER":     "alice",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain text", expected: "plain text"},
+		{input: "${BASE_URL}/login", expected: "https://staging.example.com/login"},
+		{input: "${ USER }@example.com", expected: "alice@example.com"},
+		{input: "${USER}-${USER}", expected: "alice-alice"},
+		{input: "cost: $5", expected: "cost: $5"},
+		{input: "literal $${USER}", expected: "literal ${USER}"},
+	}
+
+	for _, tt := range tests {
+		actual, err := Expand(tt.input, lookup)
+		if err != nil {
+			t.Errorf("Expand(%q): expected no error, got %v", tt.input, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("Expand(%q): expected %q, got %q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	lookup := func(string) (string, bool) { return "", false }
+
+	_, err := Expand("${MISSING}/path", lookup)
+	var undefinedErr *UndefinedError
+	if !errors.As(err, &undefinedErr) || undefinedErr.Name != "MISSING" {
+		t.Errorf("Expected undefined variable error for MISSING, got %v", err)
+	}
+	if err != nil && err.Error() != "undefined variable: MISSING" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	if _, err := Expand("${UNTERMINATED", lookup); err == nil {
+		t.Error("Expected error for unterminated reference, got nil")
+	}
+
+	if _, err := Expand("${}", lookup); err == nil {
+		t.Error("Expected error for empty reference, got nil")
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	key, value, err := ParseAssignment("BASE_URL=https://example.com/?a=b")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if key != "BASE_URL" || value != "https://example.com/?a=b" {
+		t.Errorf("Unexpected assignment %q=%q", key, value)
+	}
+
+	if _, _, err := ParseAssignment("novalue"); err == nil {
+		t.Error("Expected error for assignment without '=', got nil")
+	}
+	if _, _, err := ParseAssignment("=value"); err == nil {
+		t.Error("Expected error for assignment without key, got nil")
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	values := map[string]string{"user": "alice"}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "${user}", expected: "alice"},
+		{input: "${ user }@example.com", expected: "alice@example.com"},
+		{input: "${BASE_URL}/users/${user}", expected: "${BASE_URL}/users/alice"},
+		{input: "$${user}", expected: "$${user}"},
+		{input: "${unterminated", expected: "${unterminated"},
+	}
+
+	for _, tt := range tests {
+		actual := Substitute(tt.input, values)
+		if actual != tt.expected {
+			t.Errorf("Substitute(%q): expected %q, got %q", tt.input, tt.expected, actual)
+		}
+	}
+}
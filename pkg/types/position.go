@@ -0,0 +1,32 @@
+package types
+
+import "fmt"
+
+// Position identifies a location in a scenario file
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// IsValid reports whether the position refers to a line in a file
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String formats the position as file:line:column. The column is left out
+// when unknown.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return p.File
+	}
+
+	location := fmt.Sprintf("%d", p.Line)
+	if p.Column > 0 {
+		location = fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if p.File == "" {
+		return location
+	}
+	return p.File + ":" + location
+}
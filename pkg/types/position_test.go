@@ -0,0 +1,22 @@
+package types
+
+import "testing"
+
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		expected string
+		pos      Position
+	}{
+		{pos: Position{File: "login.yml", Line: 14, Column: 5}, expected: "login.yml:14:5"},
+		{pos: Position{Line: 3, Column: 7}, expected: "3:7"},
+		{pos: Position{File: "login.yml", Line: 2}, expected: "login.yml:2"},
+		{pos: Position{File: "login.yml"}, expected: "login.yml"},
+		{pos: Position{}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.pos.String(); actual != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+// Result statuses for scenarios and steps
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+	// StatusFlaky marks a scenario that failed and then passed on a retry
+	StatusFlaky = "flaky"
+)
+
+// ScenarioResult represents the outcome of running a single scenario file
+type ScenarioResult struct {
+	Err      error
+	Name     string
+	File     string
+	Status   string
+	Steps    []StepResult
+	Duration time.Duration
+
+	// Files saved while running the scenario, such as screenshots
+	Artifacts []string
+
+	// Number of times the scenario was run, and the failures of the runs
+	// before the last one when it was retried
+	Attempts    int
+	RetriedErrs []error
+}
+
+// StepResult represents the outcome of a single step within a scenario
+type StepResult struct {
+	Err      error
+	Type     string
+	Pos      Position
+	Status   string
+	Index    int
+	Duration time.Duration
+}
+
+// Failed reports whether the scenario did not pass
+func (r *ScenarioResult) Failed() bool {
+	return r.Status == StatusFailed
+}
@@ -1,9 +1,30 @@
 package types
 
+import "fmt"
+
 // Scenario represents a test scenario containing multiple steps
 type Scenario struct {
 	Description string `yaml:"desc" json:"description"`
 	Steps       []Step `yaml:"steps" json:"steps"`
+
+	// Path of the file the scenario was parsed from, if any
+	File string `yaml:"-" json:"file,omitempty"`
+
+	// Variables available to ${NAME} references in step fields
+	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+
+	// Overall deadline for the whole scenario in milliseconds (0 means no deadline)
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// When to keep a video of the scenario, overriding Config.Video
+	Video string `yaml:"video,omitempty" json:"video,omitempty"`
+
+	// How many times to rerun the scenario after a failure, overriding
+	// Config.Retries when set
+	Retries *int `yaml:"retries,omitempty" json:"retries,omitempty"`
+
+	// Make every assertion of the scenario soft, as if each had Soft set
+	SoftAssertions bool `yaml:"soft_assertions,omitempty" json:"soft_assertions,omitempty"`
 }
 
 // Step represents a single action in a test scenario
@@ -11,6 +32,9 @@ type Step struct {
 	// Raw YAML data for complex parsing
 	Raw map[string]interface{} `yaml:",inline" json:"-"`
 
+	// Location of the step in its source file
+	Pos Position `yaml:"-" json:"position,omitempty"`
+
 	// For simple steps like "goto: url"
 	Type string `yaml:"type,omitempty" json:"type,omitempty"`
 	URL  string `yaml:"url,omitempty" json:"url,omitempty"`
@@ -19,9 +43,105 @@ type Step struct {
 	Selector string `yaml:"selector,omitempty" json:"selector,omitempty"`
 	Value    string `yaml:"value,omitempty" json:"value,omitempty"`
 
-	// For assertion steps
+	// For assertion steps. Not negates the assertion; Soft records a failure
+	// and lets the scenario continue; Equals, Min and Max bound a count
+	// assertion; Property names the CSS property of a css assertion. The
+	// expected value of attribute, value, title and css assertions is in Value.
 	AssertType string `yaml:"type,omitempty" json:"assert_type,omitempty"`
 	Contains   string `yaml:"contains,omitempty" json:"contains,omitempty"`
+	Not        bool   `yaml:"not,omitempty" json:"not,omitempty"`
+	Soft       bool   `yaml:"soft,omitempty" json:"soft,omitempty"`
+	Equals     string `yaml:"equals,omitempty" json:"equals,omitempty"`
+	Min        *int   `yaml:"min,omitempty" json:"min,omitempty"`
+	Max        *int   `yaml:"max,omitempty" json:"max,omitempty"`
+	Property   string `yaml:"property,omitempty" json:"property,omitempty"`
+
+	// Match modes of text, url and title assertions besides Contains and
+	// Equals, and how the texts are compared
+	Matches             string `yaml:"matches,omitempty" json:"matches,omitempty"`
+	StartsWith          string `yaml:"starts_with,omitempty" json:"starts_with,omitempty"`
+	EndsWith            string `yaml:"ends_with,omitempty" json:"ends_with,omitempty"`
+	NormalizeWhitespace bool   `yaml:"normalize_whitespace,omitempty" json:"normalize_whitespace,omitempty"`
+	IgnoreCase          bool   `yaml:"ignore_case,omitempty" json:"ignore_case,omitempty"`
+
+	// For keyboard steps: the key or chord to press, such as "Control+K",
+	// and the delay in milliseconds between keys typed by a type step
+	Key   string `yaml:"key,omitempty" json:"key,omitempty"`
+	Delay int    `yaml:"delay,omitempty" json:"delay,omitempty"`
+
+	// For mouse steps: the button and the modifier keys held during a click,
+	// the point to click relative to the element (or to the page when there
+	// is no selector), and the element a drag step drops onto
+	Button    string   `yaml:"button,omitempty" json:"button,omitempty"`
+	Modifiers []string `yaml:"modifiers,omitempty" json:"modifiers,omitempty"`
+	Point     *Point   `yaml:"position,omitempty" json:"point,omitempty"`
+	Target    string   `yaml:"to,omitempty" json:"target,omitempty"`
+
+	// For form steps: the options a select step picks (or a selected
+	// assertion expects) by value, label or index, and the files an upload
+	// step sets, relative to the file the step is written in
+	OptionValues  []string `yaml:"values,omitempty" json:"option_values,omitempty"`
+	OptionLabels  []string `yaml:"labels,omitempty" json:"option_labels,omitempty"`
+	OptionIndexes []int    `yaml:"indexes,omitempty" json:"option_indexes,omitempty"`
+	Files         []string `yaml:"files,omitempty" json:"files,omitempty"`
+
+	// For wait steps: the element state (attached, detached, visible or hidden)
+	// or page load state (load, domcontentloaded or networkidle) to wait for,
+	// or a fixed time to sleep in milliseconds
+	State     string `yaml:"state,omitempty" json:"state,omitempty"`
+	LoadState string `yaml:"load_state,omitempty" json:"load_state,omitempty"`
+	Sleep     int    `yaml:"sleep,omitempty" json:"sleep,omitempty"`
+
+	// For screenshot steps: the PNG file to write, relative to the output
+	// directory, and whether to capture the whole scrollable page instead of
+	// the viewport. A selector captures a single element.
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	FullPage bool   `yaml:"full_page,omitempty" json:"full_page,omitempty"`
+
+	// For retry_until steps: the steps to run again until all of them pass,
+	// the most times to run them and the pause between attempts in
+	// milliseconds. If blocks run Steps when their condition holds and Else
+	// otherwise.
+	Steps       []Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+	Else        []Step `yaml:"else,omitempty" json:"else,omitempty"`
+	MaxAttempts int    `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	Interval    *int   `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// For store steps that save a value from the page into a variable
+	Variable   string `yaml:"name,omitempty" json:"variable,omitempty"`
+	Source     string `yaml:"from,omitempty" json:"source,omitempty"`
+	Attribute  string `yaml:"attribute,omitempty" json:"attribute,omitempty"`
+	Expression string `yaml:"expression,omitempty" json:"expression,omitempty"`
+
+	// Timeout for this step in milliseconds, overriding the global timeout
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Conditions checked when the step is reached: the step is skipped unless
+	// If holds and Unless does not. The condition of an if block is in If, or
+	// in Unless for a block written with unless.
+	If     *Condition `yaml:"if,omitempty" json:"if,omitempty"`
+	Unless *Condition `yaml:"unless,omitempty" json:"unless,omitempty"`
+}
+
+// Condition is a check of the page or of a variable, made without waiting.
+// Exactly one of Exists, Visible, URL, URLMatches and Variable is set.
+type Condition struct {
+	Exists     string `yaml:"exists,omitempty" json:"exists,omitempty"`           // Selector of an element on the page
+	Visible    string `yaml:"visible,omitempty" json:"visible,omitempty"`         // Selector of a visible element
+	URL        string `yaml:"url,omitempty" json:"url,omitempty"`                 // Text contained in the page URL
+	URLMatches string `yaml:"url_matches,omitempty" json:"url_matches,omitempty"` // Regular expression matching the page URL
+
+	// Variable holds when it is set and not empty, or compares its value
+	// with Equals or NotEquals when one of them is given
+	Variable  string  `yaml:"var,omitempty" json:"var,omitempty"`
+	Equals    *string `yaml:"equals,omitempty" json:"equals,omitempty"`
+	NotEquals *string `yaml:"not_equals,omitempty" json:"not_equals,omitempty"`
+}
+
+// Point is a position in CSS pixels
+type Point struct {
+	X float64 `yaml:"x" json:"x"`
+	Y float64 `yaml:"y" json:"y"`
 }
 
 // Config represents configuration for the test execution
@@ -29,4 +149,131 @@ type Config struct {
 	Browser  string `yaml:"browser,omitempty" json:"browser,omitempty"`
 	Headless bool   `yaml:"headless,omitempty" json:"headless,omitempty"`
 	Timeout  int    `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Default overall deadline for each scenario in milliseconds (0 means no deadline)
+	ScenarioTimeout int `yaml:"scenario_timeout,omitempty" json:"scenario_timeout,omitempty"`
+
+	// Directory that receives reports and other run artifacts
+	OutputDir string `yaml:"output_dir,omitempty" json:"output_dir,omitempty"`
+
+	// When to record a Playwright trace of each scenario: off, on or retain-on-failure
+	Trace string `yaml:"trace,omitempty" json:"trace,omitempty"`
+
+	// When to keep a video of each scenario: off, on or retain-on-failure
+	Video string `yaml:"video,omitempty" json:"video,omitempty"`
+
+	// How many times to rerun a failed scenario before reporting it failed
+	Retries int `yaml:"retries,omitempty" json:"retries,omitempty"`
+
+	// Variables given on the command line, overriding scenario variables
+	Vars map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+// Recording modes for traces and videos
+const (
+	RecordOff             = "off"
+	RecordOn              = "on"
+	RecordRetainOnFailure = "retain-on-failure"
+)
+
+// Describe returns a short human-readable summary of the step,
+// such as `click "#submit"`, for use in messages
+func (s Step) Describe() string {
+	switch {
+	case s.Type == "assert" && s.Not && s.Selector != "":
+		return fmt.Sprintf("assert not %s %q", s.AssertType, s.Selector)
+	case s.Type == "assert" && s.Not:
+		return fmt.Sprintf("assert not %s", s.AssertType)
+	case s.Type == "assert" && s.Selector != "":
+		return fmt.Sprintf("assert %s %q", s.AssertType, s.Selector)
+	case s.Type == "assert":
+		return fmt.Sprintf("assert %s", s.AssertType)
+	case s.Type == "store":
+		return fmt.Sprintf("store %s", s.Variable)
+	case s.Type == "if" && s.Unless != nil:
+		return "unless"
+	case s.Type == "wait" && s.Sleep > 0:
+		return fmt.Sprintf("wait %dms", s.Sleep)
+	case s.Type == "drag":
+		return fmt.Sprintf("drag %q to %q", s.Selector, s.Target)
+	case s.Selector == "" && s.Point != nil:
+		return fmt.Sprintf("%s at (%g, %g)", s.Type, s.Point.X, s.Point.Y)
+	case s.Key != "" && s.Selector != "":
+		return fmt.Sprintf("%s %q on %q", s.Type, s.Key, s.Selector)
+	case s.Key != "":
+		return fmt.Sprintf("%s %q", s.Type, s.Key)
+	case s.URL != "":
+		return fmt.Sprintf("%s %q", s.Type, s.URL)
+	case s.Selector != "":
+		return fmt.Sprintf("%s %q", s.Type, s.Selector)
+	default:
+		return s.Type
+	}
+}
+
+// MapStrings returns a copy of the step with fn applied to every user-supplied
+// string field, such as when expanding ${NAME} variable references
+func (s Step) MapStrings(fn func(string) (string, error)) (Step, error) {
+	fields := []*string{
+		&s.URL, &s.Selector, &s.Value, &s.Contains, &s.Key, &s.Target,
+		&s.Attribute, &s.Expression, &s.Path, &s.Equals, &s.Property,
+		&s.Matches, &s.StartsWith, &s.EndsWith,
+	}
+	for _, field := range fields {
+		value, err := fn(*field)
+		if err != nil {
+			return s, err
+		}
+		*field = value
+	}
+
+	for _, condition := range []**Condition{&s.If, &s.Unless} {
+		if *condition == nil {
+			continue
+		}
+		mapped, err := (*condition).mapStrings(fn)
+		if err != nil {
+			return s, err
+		}
+		*condition = mapped
+	}
+
+	lists := []*[]string{&s.OptionValues, &s.OptionLabels, &s.Files}
+	for _, list := range lists {
+		if *list == nil {
+			continue
+		}
+		mapped := make([]string, len(*list))
+		for i, item := range *list {
+			value, err := fn(item)
+			if err != nil {
+				return s, err
+			}
+			mapped[i] = value
+		}
+		*list = mapped
+	}
+	return s, nil
+}
+
+// mapStrings returns a copy of the condition with fn applied to every
+// user-supplied value. The variable name is kept as written.
+func (c *Condition) mapStrings(fn func(string) (string, error)) (*Condition, error) {
+	mapped := *c
+	fields := []*string{&mapped.Exists, &mapped.Visible, &mapped.URL, &mapped.URLMatches}
+	for _, comparison := range []**string{&mapped.Equals, &mapped.NotEquals} {
+		if *comparison != nil {
+			value := **comparison
+			*comparison = &value
+			fields = append(fields, &value)
+		}
+	}
+	for _, field := range fields {
+		value, err := fn(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &mapped, nil
 }
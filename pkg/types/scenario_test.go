@@ -1,6 +1,7 @@
 package types
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -92,3 +93,69 @@ func TestConfigStructure(t *testing.T) {
 		t.Errorf("Expected timeout 30000, got %d", config.Timeout)
 	}
 }
+
+func TestStepMapStrings(t *testing.T) {
+	env := "prod"
+	step := Step{
+		Type:     "fill",
+		Selector: "#email",
+		Value:    "user",
+		Files:    []string{"a.png", "b.png"},
+		If:       &Condition{Variable: "env", Equals: &env},
+	}
+
+	mapped, err := step.MapStrings(func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if mapped.Selector != "#EMAIL" || mapped.Value != "USER" {
+		t.Errorf("Expected mapped fields, got selector '%s' value '%s'", mapped.Selector, mapped.Value)
+	}
+	if strings.Join(mapped.Files, ",") != "A.PNG,B.PNG" {
+		t.Errorf("Expected mapped list fields, got %v", mapped.Files)
+	}
+	if step.Files[0] != "a.png" {
+		t.Errorf("Expected original list to be unchanged, got %v", step.Files)
+	}
+	if mapped.Type != "fill" {
+		t.Errorf("Expected step type to be left untouched, got '%s'", mapped.Type)
+	}
+	if step.Selector != "#email" {
+		t.Errorf("Expected original step to be unchanged, got '%s'", step.Selector)
+	}
+	if mapped.If.Variable != "env" || *mapped.If.Equals != "PROD" {
+		t.Errorf("Expected mapped comparison with the variable name kept, got %+v", mapped.If)
+	}
+	if env != "prod" {
+		t.Errorf("Expected original condition to be unchanged, got '%s'", env)
+	}
+}
+
+func TestStepDescribe(t *testing.T) {
+	tests := []struct {
+		expected string
+		step     Step
+	}{
+		{step: Step{Type: "click", Selector: "#submit"}, expected: `click "#submit"`},
+		{step: Step{Type: "goto", URL: "https://example.com"}, expected: `goto "https://example.com"`},
+		{step: Step{Type: "assert", AssertType: "url", Contains: "/home"}, expected: "assert url"},
+		{step: Step{Type: "assert", AssertType: "exists", Selector: "#ok"}, expected: `assert exists "#ok"`},
+		{step: Step{Type: "store", Variable: "order_id", Selector: "#id"}, expected: "store order_id"},
+		{step: Step{Type: "press", Key: "Enter"}, expected: `press "Enter"`},
+		{step: Step{Type: "wait", Sleep: 500}, expected: "wait 500ms"},
+		{step: Step{Type: "assert", AssertType: "visible", Selector: "#toast", Not: true}, expected: `assert not visible "#toast"`},
+		{step: Step{Type: "wait", Selector: "#done", State: "visible"}, expected: `wait "#done"`},
+		{step: Step{Type: "drag", Selector: "#card", Target: "#done"}, expected: `drag "#card" to "#done"`},
+		{step: Step{Type: "click", Point: &Point{X: 10, Y: 20.5}}, expected: "click at (10, 20.5)"},
+		{step: Step{Type: "press", Key: "Control+K", Selector: "#q"}, expected: `press "Control+K" on "#q"`},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.step.Describe(); actual != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, actual)
+		}
+	}
+}